@@ -23,6 +23,12 @@ public partial class ApiClient : Node
     /// Should never be null, since it is configured to autoload in Godot, and set in _EnterTree.
     public static ApiClient Instance { get; private set; } = null!;
 
+    /// <summary>
+    /// Version of the WebSocket protocol implemented by this client. Must be within the range
+    /// supported by the server (see ProtocolVersion in the server's lobby/protocol.go).
+    /// </summary>
+    public const int ProtocolVersion = 1;
+
     public Uri? ServerUrl => _httpClient?.BaseAddress;
 
     private HttpClient? _httpClient = null;
@@ -182,7 +188,7 @@ public partial class ApiClient : Node
             Host = ServerUrl.Host,
             Port = ServerUrl.Port,
             Path = "/join",
-            Query = $"lobbyName={lobbyName}&username={username}"
+            Query = $"lobbyName={lobbyName}&username={username}&protocolVersion={ProtocolVersion}"
         };
 
         try
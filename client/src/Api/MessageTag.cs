@@ -4,23 +4,27 @@ using System.Linq;
 
 namespace CasusBelli.Client.Api;
 
+/// <summary>
+/// Must match the explicitly numbered MessageTag constants in the server's lobby/messages.go.
+/// Values must never change once released.
+/// </summary>
 public enum MessageTag
 {
     Error = 1,
-    LobbyJoined,
-    PlayerStatus,
-    SelectFaction,
-    StartGame,
-    GameStarted,
-    OrderRequest,
-    OrdersConfirmation,
-    OrdersReceived,
-    BattleAnnouncement,
-    BattleResults,
-    Winner,
-    SubmitOrders,
-    DiceRoll,
-    GiveSupport
+    LobbyJoined = 2,
+    PlayerStatus = 3,
+    SelectFaction = 4,
+    StartGame = 5,
+    GameStarted = 6,
+    OrderRequest = 7,
+    OrdersConfirmation = 8,
+    OrdersReceived = 9,
+    BattleAnnouncement = 10,
+    BattleResults = 11,
+    Winner = 12,
+    SubmitOrders = 13,
+    DiceRoll = 14,
+    GiveSupport = 15
 }
 
 public static class MessageTagMap
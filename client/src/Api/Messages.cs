@@ -46,6 +46,7 @@ public partial class LobbyJoinedMessage : GodotObject, IReceivableMessage
 {
     public required List<string> SelectableFactions { get; set; }
     public required List<PlayerStatusMessage> PlayerStatuses { get; set; }
+    public required int ProtocolVersion { get; set; }
 }
 
 /// <summary>
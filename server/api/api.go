@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,56 +13,85 @@ import (
 
 	"hermannm.dev/casus-belli/server/game"
 	"hermannm.dev/casus-belli/server/lobby"
+	"hermannm.dev/casus-belli/server/metrics"
 )
 
 type LobbyAPI struct {
 	router          *http.ServeMux
+	server          *http.Server
 	lobbyRegistry   *lobby.LobbyRegistry
 	availableBoards []game.BoardInfo
+
+	// Set by RegisterLobbyCreationEndpoints, before the server starts.
+	lobbyCreationEnabled bool
 }
 
 func NewLobbyAPI(
 	router *http.ServeMux,
 	lobbyRegistry *lobby.LobbyRegistry,
 	availableBoards []game.BoardInfo,
-) LobbyAPI {
+) *LobbyAPI {
 	if router == nil {
 		router = http.DefaultServeMux
 	}
 
-	api := LobbyAPI{lobbyRegistry: lobbyRegistry, availableBoards: availableBoards, router: router}
+	api := &LobbyAPI{
+		router: router,
+		server: &http.Server{
+			Handler:           router,
+			ReadHeaderTimeout: 3 * time.Second,
+		},
+		lobbyRegistry:        lobbyRegistry,
+		availableBoards:      availableBoards,
+		lobbyCreationEnabled: false,
+	}
 
 	router.HandleFunc("GET /lobbies", api.listLobbies)
 	router.HandleFunc("GET /join", api.joinLobby)
+	router.HandleFunc("GET /metrics", api.showMetrics)
+	router.HandleFunc("GET /healthz", api.checkHealth)
+	router.HandleFunc("GET /readyz", api.checkReadiness)
+	router.HandleFunc("GET /info", api.showServerInfo)
 
 	return api
 }
 
-func (api LobbyAPI) RegisterLobbyCreationEndpoints() {
+func (api *LobbyAPI) RegisterLobbyCreationEndpoints() {
 	api.router.HandleFunc("POST /create", api.createLobby)
 	api.router.HandleFunc("GET /boards", api.listBoards)
+	api.lobbyCreationEnabled = true
 }
 
-func (api LobbyAPI) ListenAndServe(address string) error {
-	server := &http.Server{
-		Addr:              address,
-		Handler:           api.router,
-		ReadHeaderTimeout: 3 * time.Second,
-	}
-	if err := server.ListenAndServe(); err != nil {
+// Returns nil if the server was stopped by [LobbyAPI.Shutdown].
+func (api *LobbyAPI) ListenAndServe(address string) error {
+	api.server.Addr = address
+	if err := api.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return wrap.Error(err, "server stopped")
 	}
 	return nil
 }
 
+// Stops accepting new connections, and shuts down all lobbies (see [lobby.LobbyRegistry.Shutdown]).
+// Running games are given until the given context is canceled to finish their current resolution
+// step. If saveDir is not blank, game state is saved there.
+func (api *LobbyAPI) Shutdown(ctx context.Context, saveDir string) error {
+	api.lobbyRegistry.Shutdown(ctx, saveDir)
+
+	if err := api.server.Shutdown(ctx); err != nil {
+		return wrap.Error(err, "failed to shut down HTTP server")
+	}
+	return nil
+}
+
 // Endpoint to list available game lobbies.
-func (api LobbyAPI) listLobbies(res http.ResponseWriter, _ *http.Request) {
+func (api *LobbyAPI) listLobbies(res http.ResponseWriter, _ *http.Request) {
 	sendJSON(res, api.lobbyRegistry.ListLobbies())
 }
 
 // Endpoint for a player to join a lobby.
-// Expects query parameters "lobbyName" and "username".
-func (api LobbyAPI) joinLobby(res http.ResponseWriter, req *http.Request) {
+// Expects query parameters "lobbyName", "username" and "protocolVersion" (see
+// [lobby.ProtocolVersion]), and "password" if the lobby has one.
+func (api *LobbyAPI) joinLobby(res http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	query := req.URL.Query()
 
@@ -82,6 +113,11 @@ func (api LobbyAPI) joinLobby(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if err := gameLobby.CheckPassword(query.Get("password")); err != nil {
+		sendForbiddenErrorWithHeader(res, err)
+		return
+	}
+
 	//nolint:exhaustruct
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  1024,
@@ -98,16 +134,18 @@ func (api LobbyAPI) joinLobby(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Checks protocol version after upgrading the socket, so that the client receives the error in
+	// a message that it can display, regardless of its version
+	if err := lobby.CheckProtocolVersion(query.Get("protocolVersion")); err != nil {
+		gameLobby.Logger().Error(ctx, err, "rejected incompatible client", "player", username)
+		rejectSocket(socket, websocket.CloseProtocolError, err)
+		return
+	}
+
 	player, err := gameLobby.AddPlayer(username, socket)
 	if err != nil {
 		gameLobby.Logger().Error(ctx, err, "failed to add player", "player", username)
-		_ = socket.WriteJSON(
-			lobby.Message{
-				Tag:  lobby.MessageTagError,
-				Data: lobby.ErrorMessage{Error: wrap.Error(err, "failed to join game").Error()},
-			},
-		)
-		_ = socket.Close()
+		rejectSocket(socket, websocket.ClosePolicyViolation, err)
 		return
 	}
 
@@ -116,8 +154,14 @@ func (api LobbyAPI) joinLobby(res http.ResponseWriter, req *http.Request) {
 }
 
 // Endpoint for creating lobbies (for servers with public lobby creation enabled).
-// Expects query parameters "lobbyName" and "boardID".
-func (api LobbyAPI) createLobby(res http.ResponseWriter, req *http.Request) {
+// Expects query parameters "lobbyName" and "boardID". Optionally takes a "password" that players
+// must provide to join, an "unlisted" flag to hide the lobby from the lobby list, a "fogOfWar" flag
+// to only show players the parts of the board near their own units and regions, a "hiddenOrders"
+// flag to only reveal other players' orders if they were involved in battles, a "retreatChoice"
+// flag to let players choose where their units retreat after battles, a "holdDefenseBonus" number
+// to add to the battle results of defending units with hold orders, and an "economy" flag to make
+// players pay for their builds with income from the regions they control.
+func (api *LobbyAPI) createLobby(res http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
 	query := req.URL.Query()
 
@@ -133,7 +177,55 @@ func (api LobbyAPI) createLobby(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := api.lobbyRegistry.CreateLobby(lobbyName, boardID, false, nil); err != nil {
+	unlisted, err := getOptionalBoolQueryParam(query, "unlisted")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	fogOfWar, err := getOptionalBoolQueryParam(query, "fogOfWar")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	hiddenOrders, err := getOptionalBoolQueryParam(query, "hiddenOrders")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	retreatChoice, err := getOptionalBoolQueryParam(query, "retreatChoice")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	holdDefenseBonus, err := getOptionalIntQueryParam(query, "holdDefenseBonus")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	economy, err := getOptionalBoolQueryParam(query, "economy")
+	if err != nil {
+		sendClientError(res, err)
+		return
+	}
+
+	options := lobby.LobbyOptions{
+		Password:     query.Get("password"),
+		Unlisted:     unlisted,
+		FogOfWar:     fogOfWar,
+		HiddenOrders: hiddenOrders,
+		Rules: game.Rules{
+			RetreatChoice:    retreatChoice,
+			HoldDefenseBonus: holdDefenseBonus,
+			Economy:          economy,
+		},
+	}
+
+	if err := api.lobbyRegistry.CreateLobby(lobbyName, boardID, false, nil, options); err != nil {
 		err = wrap.Error(err, "failed to create lobby")
 		sendServerError(res, err)
 		log.Error(ctx, err, "")
@@ -144,6 +236,15 @@ func (api LobbyAPI) createLobby(res http.ResponseWriter, req *http.Request) {
 }
 
 // Endpoint for showing the list of boards supported by the server.
-func (api LobbyAPI) listBoards(res http.ResponseWriter, _ *http.Request) {
+func (api *LobbyAPI) listBoards(res http.ResponseWriter, _ *http.Request) {
 	sendJSON(res, api.availableBoards)
 }
+
+// Endpoint for showing server metrics in the Prometheus text format.
+func (api *LobbyAPI) showMetrics(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := metrics.Default.WriteText(res); err != nil {
+		log.Error(req.Context(), wrap.Error(err, "failed to write metrics"), "")
+	}
+}
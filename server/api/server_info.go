@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"hermannm.dev/casus-belli/server/lobby"
+)
+
+// Response body of the GET /info endpoint.
+type ServerInfo struct {
+	// Module version of the server, or "(devel)" if built from a local checkout.
+	Version string
+
+	GoVersion string
+
+	// Blank if the server was built without version control information.
+	Revision string `json:",omitempty"`
+
+	// Whether the server was built from a version control checkout with uncommitted changes.
+	Modified bool `json:",omitempty"`
+
+	// Whether clients can create lobbies on this server, through the POST /create endpoint.
+	// False for servers started with the -local or -dev flags.
+	LobbyCreationEnabled bool
+
+	// Range of WebSocket protocol versions supported by the server (see [lobby.ProtocolVersion]).
+	ProtocolVersion             int
+	MinSupportedProtocolVersion int
+}
+
+// Endpoint for showing the server's version, build info and enabled features.
+func (api *LobbyAPI) showServerInfo(res http.ResponseWriter, _ *http.Request) {
+	info := ServerInfo{
+		Version:              "unknown",
+		GoVersion:            "unknown",
+		Revision:             "",
+		Modified:             false,
+		LobbyCreationEnabled: api.lobbyCreationEnabled,
+
+		ProtocolVersion:             lobby.ProtocolVersion,
+		MinSupportedProtocolVersion: lobby.MinSupportedProtocolVersion,
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		info.Version = buildInfo.Main.Version
+		info.GoVersion = buildInfo.GoVersion
+
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	sendJSON(res, info)
+}
+
+// Endpoint for checking that the server process is up.
+func (api *LobbyAPI) checkHealth(res http.ResponseWriter, _ *http.Request) {
+	res.WriteHeader(http.StatusOK)
+}
+
+// Endpoint for checking whether the server accepts new lobbies and players. Responds with 503
+// Service Unavailable when the server is shutting down.
+func (api *LobbyAPI) checkReadiness(res http.ResponseWriter, _ *http.Request) {
+	if api.lobbyRegistry.ShuttingDown() {
+		http.Error(res, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+}
@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"hermannm.dev/devlog/log"
 	"hermannm.dev/wrap"
+
+	"hermannm.dev/casus-belli/server/lobby"
 )
 
 func getQueryParam(query url.Values, paramName string) (string, error) {
@@ -24,6 +29,36 @@ func getQueryParam(query url.Values, paramName string) (string, error) {
 	return unescaped, nil
 }
 
+// Returns false if the query param is not set.
+func getOptionalBoolQueryParam(query url.Values, paramName string) (bool, error) {
+	value := query.Get(paramName)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, wrap.Errorf(err, "failed to parse query param '%s' as boolean", paramName)
+	}
+
+	return parsed, nil
+}
+
+// Returns 0 if the query param is not set.
+func getOptionalIntQueryParam(query url.Values, paramName string) (int, error) {
+	value := query.Get(paramName)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, wrap.Errorf(err, "failed to parse query param '%s' as integer", paramName)
+	}
+
+	return parsed, nil
+}
+
 func sendJSON(res http.ResponseWriter, value any) {
 	res.Header().Set("Content-Type", "application/json")
 
@@ -53,8 +88,33 @@ func sendClientErrorWithHeader(res http.ResponseWriter, err error) {
 	http.Error(res, errMessage, http.StatusBadRequest)
 }
 
+func sendForbiddenErrorWithHeader(res http.ResponseWriter, err error) {
+	errMessage := err.Error()
+	res.Header().Set("Error", errMessage)
+	http.Error(res, errMessage, http.StatusForbidden)
+}
+
 func sendServerErrorWithHeader(res http.ResponseWriter, err error) {
 	errMessage := err.Error()
 	res.Header().Set("Error", errMessage)
 	http.Error(res, errMessage, http.StatusInternalServerError)
 }
+
+// Sends an error message over the given socket for a player that could not join a lobby, then
+// closes the connection with the given close code.
+func rejectSocket(socket *websocket.Conn, closeCode int, err error) {
+	errMessage := wrap.Error(err, "failed to join game").Error()
+
+	_ = socket.WriteJSON(
+		lobby.Message{
+			Tag:  lobby.MessageTagError,
+			Data: lobby.ErrorMessage{Error: errMessage, Details: nil, OrderErrors: nil},
+		},
+	)
+	_ = socket.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(closeCode, ""),
+		time.Now().Add(time.Second),
+	)
+	_ = socket.Close()
+}
@@ -73,35 +73,38 @@ func (faction PlayerFaction) isFighting(battle *Battle) bool {
 	return false
 }
 
-func (game *Game) resolveSingleplayerBattle(region *Region) {
+func (game *Game) resolveSingleplayerBattle(ctx context.Context, region *Region) {
 	move := region.incomingMoves[0]
 	battle := Battle{
 		Results:    []Result{game.newAttackerResult(move, region, true, false)},
 		DangerZone: "",
 	}
 
-	game.calculateBattle(&battle, region)
+	battlesMetric.Inc(battleKindSingleplayer)
+	game.calculateBattle(ctx, &battle, region)
 
 	winners, _ := battle.winnersAndLosers()
 	if len(winners) == 1 {
-		game.board.succeedMove(move)
+		game.board.succeedMove(move, game.UnitTypes, &game.trace, ResolutionCauseWonBattle)
 	} else {
-		game.board.retreatMove(move)
+		game.retreatMove(move, ResolutionCauseLostBattle)
 	}
 
 	game.messenger.SendBattleResults(battle)
 }
 
-func (game *Game) resolveMultiplayerBattle(region *Region) {
+func (game *Game) resolveMultiplayerBattle(ctx context.Context, region *Region) {
 	var battle Battle
 	for _, move := range region.incomingMoves {
 		battle.Results = append(battle.Results, game.newAttackerResult(move, region, false, false))
 	}
 	if !region.empty() {
-		battle.Results = append(battle.Results, game.newDefenderResult(*region.Unit))
+		holding := region.order != nil && region.order.Type == OrderHold
+		battle.Results = append(battle.Results, game.newDefenderResult(*region.Unit, holding))
 	}
 
-	game.calculateBattle(&battle, region)
+	battlesMetric.Inc(battleKindMultiplayer)
+	game.calculateBattle(ctx, &battle, region)
 
 	winners, losers := battle.winnersAndLosers()
 	tie := len(winners) > 1
@@ -115,28 +118,33 @@ func (game *Game) resolveMultiplayerBattle(region *Region) {
 			// or the defender lost but did not control the region, we have to remove the unit here.
 			if slices.Contains(losers, result.DefenderFaction) {
 				// Guaranteed to have 1 element, since this is not a border battle
-				regionName := battle.regionNames()[0]
+				regionName := battle.RegionNames()[0]
 				region := game.board[regionName]
 				if tie || !region.controlled() {
+					cause := ResolutionCauseLostBattle
+					if tie {
+						cause = ResolutionCauseTiedBattle
+					}
+					game.trace.add(ResolutionEventUnitKilled, cause, nil, regionName)
 					region.removeUnit()
 				}
 			}
 			continue
 		} else if move := result.Order; move != nil {
 			if slices.Contains(losers, move.Faction) {
-				game.board.killMove(move)
+				game.board.killMove(move, &game.trace, ResolutionCauseLostBattle)
 				continue
 			}
 
 			if tie {
-				game.board.retreatMove(move)
+				game.retreatMove(move, ResolutionCauseTiedBattle)
 				continue
 			}
 
 			// If the destination is not controlled, then the winner will have to battle there before we
 			// can succeed the move
 			if game.board[move.Destination].controlled() {
-				game.board.succeedMove(move)
+				game.board.succeedMove(move, game.UnitTypes, &game.trace, ResolutionCauseWonBattle)
 			}
 		}
 	}
@@ -144,12 +152,12 @@ func (game *Game) resolveMultiplayerBattle(region *Region) {
 	game.messenger.SendBattleResults(battle)
 }
 
-func (game *Game) calculateBattle(battle *Battle, region *Region) {
+func (game *Game) calculateBattle(ctx context.Context, battle *Battle, region *Region) {
 	remainingSupports := battle.addAutomaticSupports(region, region.incomingMoves, false)
 
 	game.messenger.SendBattleAnnouncement(*battle)
 
-	ctx, cleanup := newPlayerInputContext()
+	ctx, cleanup := newPlayerInputContext(ctx)
 	defer cleanup()
 
 	// If we have no supports to call, and only 1 combatant, then we can avoid concurrency
@@ -193,7 +201,7 @@ func (game *Game) calculateBattle(battle *Battle, region *Region) {
 }
 
 // Battle where units from two regions attack each other simultaneously.
-func (game *Game) resolveBorderBattle(region1 *Region, region2 *Region) {
+func (game *Game) resolveBorderBattle(ctx context.Context, region1 *Region, region2 *Region) {
 	moveToRegion1, moveToRegion2 := region2.order, region1.order
 	battle := Battle{
 		Results: []Result{
@@ -203,7 +211,8 @@ func (game *Game) resolveBorderBattle(region1 *Region, region2 *Region) {
 		DangerZone: "",
 	}
 
-	game.calculateBorderBattle(&battle, region1, region2)
+	battlesMetric.Inc(battleKindBorder)
+	game.calculateBorderBattle(ctx, &battle, region1, region2)
 
 	winners, losers := battle.winnersAndLosers()
 
@@ -213,15 +222,15 @@ func (game *Game) resolveBorderBattle(region1 *Region, region2 *Region) {
 		game.board.removeOrder(moveToRegion1)
 		game.board.removeOrder(moveToRegion2)
 
-		game.board.retreatMove(moveToRegion1)
-		game.board.retreatMove(moveToRegion2)
+		game.retreatMove(moveToRegion1, ResolutionCauseTiedBorderBattle)
+		game.retreatMove(moveToRegion2, ResolutionCauseTiedBorderBattle)
 	} else {
 		for _, result := range battle.Results {
 			// Only the loser is affected by the results of the border battle; the winner may still
 			// have to win a battle in the destination region, which will be handled by the next
 			// cycle of move resolving.
 			if result.Order.Faction == losers[0] {
-				game.board.killMove(result.Order)
+				game.board.killMove(result.Order, &game.trace, ResolutionCauseLostBorderBattle)
 				break
 			}
 		}
@@ -232,13 +241,18 @@ func (game *Game) resolveBorderBattle(region1 *Region, region2 *Region) {
 
 var errSupportedOtherRegion = errors.New("supported other region in border battle")
 
-func (game *Game) calculateBorderBattle(battle *Battle, region1 *Region, region2 *Region) {
+func (game *Game) calculateBorderBattle(
+	ctx context.Context,
+	battle *Battle,
+	region1 *Region,
+	region2 *Region,
+) {
 	remainingSupports1 := battle.addAutomaticSupports(region1, []*Order{region2.order}, true)
 	remainingSupports2 := battle.addAutomaticSupports(region2, []*Order{region1.order}, true)
 
 	game.messenger.SendBattleAnnouncement(*battle)
 
-	ctx, cleanup := newPlayerInputContext()
+	ctx, cleanup := newPlayerInputContext(ctx)
 	defer cleanup()
 
 	var resultsLock sync.Mutex
@@ -254,13 +268,16 @@ func (game *Game) calculateBorderBattle(battle *Battle, region1 *Region, region2
 		supportCount1 := countOrdersFromFaction(remainingSupports1, faction)
 		supportCount2 := countOrdersFromFaction(remainingSupports2, faction)
 
-		//nolint:govet // We wait for both goroutines to complete below
-		ctx, cancel := context.WithCancelCause(ctx)
+		// A faction supporting both regions may only support one of them, so the first support
+		// declaration cancels the other. Canceled again with a nil cause after both complete.
+		supportCtx, cancelSupport := context.WithCancelCause(ctx)
+		defer cancelSupport(nil)
+
 		if supportCount1 != 0 {
 			waitGroup.Add(1)
 			go func() {
 				game.awaitSupport(
-					ctx,
+					supportCtx,
 					faction,
 					battle,
 					region1.Name,
@@ -269,7 +286,7 @@ func (game *Game) calculateBorderBattle(battle *Battle, region1 *Region, region2
 					&waitGroup,
 					&resultsLock,
 				)
-				cancel(errSupportedOtherRegion)
+				cancelSupport(errSupportedOtherRegion)
 			}()
 		}
 
@@ -277,7 +294,7 @@ func (game *Game) calculateBorderBattle(battle *Battle, region1 *Region, region2
 			waitGroup.Add(1)
 			go func() {
 				game.awaitSupport(
-					ctx,
+					supportCtx,
 					faction,
 					battle,
 					region2.Name,
@@ -286,13 +303,13 @@ func (game *Game) calculateBorderBattle(battle *Battle, region1 *Region, region2
 					&waitGroup,
 					&resultsLock,
 				)
-				cancel(errSupportedOtherRegion)
+				cancelSupport(errSupportedOtherRegion)
 			}()
 		}
 	}
 
 	waitGroup.Wait()
-} //nolint:govet // We wait for both goroutines to complete with waitGroup.Wait()
+}
 
 func (game *Game) awaitDiceRoll(
 	ctx context.Context,
@@ -343,7 +360,10 @@ func (game *Game) awaitSupport(
 
 	if !slices.Contains(supportableFactions, supported) {
 		game.handleBattleError(
-			fmt.Errorf("received invalid supported faction '%s'", supported),
+			newInputError(
+				ErrorCodeInvalidSupportedFaction,
+				fmt.Errorf("received invalid supported faction '%s'", supported),
+			).withRegion(regionName).withMismatch("", string(supported)),
 			faction,
 			battle,
 		)
@@ -356,39 +376,51 @@ func (game *Game) awaitSupport(
 }
 
 func (game *Game) handleBattleError(err error, faction PlayerFaction, battle *Battle) {
+	err = handleTimeout(err)
 	game.messenger.SendError(faction, err)
-	game.log.WarnError(nil, err, "", "from", faction, "battle", battle.regionNames())
+	game.log.WarnError(nil, err, "", "from", faction, "battle", battle.RegionNames())
 }
 
-// Adds modifiers for support orders from players involved in the battle, as we assume they always
-// want to support themselves, so we don't need to ask. Returns support orders that could not be
-// added automatically.
+// Adds supports to the battle from players supporting themselves, and from support orders that
+// named the faction to support when submitted (see [Order.SupportedFaction]). Returns the remaining
+// supports, for which players must be asked who to support.
 func (battle *Battle) addAutomaticSupports(
 	region *Region,
 	incomingMoves []*Order,
 	borderBattle bool,
 ) (remainingSupports []*Order) {
-	supportCounts := make(map[PlayerFaction]int)
+	supportableFactions := make([]PlayerFaction, 0, len(incomingMoves)+1)
+	for _, move := range incomingMoves {
+		supportableFactions = append(supportableFactions, move.Faction)
+	}
+	if !region.empty() && !borderBattle {
+		supportableFactions = append(supportableFactions, region.Unit.Faction)
+	}
+
+	type supportKey struct {
+		supporting PlayerFaction
+		supported  PlayerFaction
+	}
+	supportCounts := make(map[supportKey]int)
 
-SupportLoop:
 	for _, support := range region.incomingSupports {
-		if !region.empty() && !borderBattle && support.Faction == region.Unit.Faction {
-			supportCounts[support.Faction]++
+		if slices.Contains(supportableFactions, support.Faction) {
+			supportCounts[supportKey{support.Faction, support.Faction}]++
 			continue
 		}
 
-		for _, move := range incomingMoves {
-			if move.Faction == support.Faction {
-				supportCounts[support.Faction]++
-				continue SupportLoop
+		if support.SupportedFaction != "" {
+			if slices.Contains(supportableFactions, support.SupportedFaction) {
+				supportCounts[supportKey{support.Faction, support.SupportedFaction}]++
 			}
+			continue
 		}
 
 		remainingSupports = append(remainingSupports, support)
 	}
 
-	for faction, supportCount := range supportCounts {
-		battle.addModifier(faction, newSupportModifier(supportCount, faction))
+	for key, supportCount := range supportCounts {
+		battle.addModifier(key.supported, newSupportModifier(supportCount, key.supporting))
 	}
 
 	return remainingSupports
@@ -431,7 +463,7 @@ func (battle Battle) winnersAndLosers() (winners []PlayerFaction, losers []Playe
 }
 
 // Returns regions involved in the battle - typically 1, but 2 if it was a border battle.
-func (battle Battle) regionNames() []RegionName {
+func (battle Battle) RegionNames() []RegionName {
 	nameSet := set.ArraySetWithCapacity[RegionName](2)
 
 	for _, result := range battle.Results {
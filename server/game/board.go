@@ -137,13 +137,22 @@ func (board Board) placeKnightMoves(region *Region) {
 //
 // Also goes through incoming supports to the region, and cuts any incoming support orders that are
 // under attack, unless we must wait for their origin regions to reach knight move resolving.
-func (board Board) cutSupportsAttackedByKnightMoves(region *Region) (mustWait bool) {
+func (board Board) cutSupportsAttackedByKnightMoves(
+	region *Region,
+	trace *resolutionTrace,
+) (mustWait bool) {
 	if region.order != nil && region.order.Type == OrderSupport {
 		destination := board[region.order.Destination]
 		if !destination.resolved && !destination.resolvingKnightMoves {
 			return true
 		}
 
+		trace.add(
+			ResolutionEventSupportCut,
+			ResolutionCauseKnightMoveAttack,
+			region.order,
+			region.Name,
+		)
 		board.removeOrder(region.order)
 	}
 
@@ -167,6 +176,12 @@ func (board Board) cutSupportsAttackedByKnightMoves(region *Region) (mustWait bo
 	}
 
 	for _, support := range supportsToCut {
+		trace.add(
+			ResolutionEventSupportCut,
+			ResolutionCauseKnightMoveAttack,
+			support,
+			support.Origin,
+		)
 		board.removeOrder(support)
 	}
 
@@ -212,16 +227,25 @@ func (board Board) removeOrder(order *Order) {
 	}
 }
 
-func (board Board) succeedMove(move *Order) {
+func (board Board) succeedMove(
+	move *Order,
+	unitTypes UnitTypes,
+	trace *resolutionTrace,
+	cause ResolutionCause,
+) {
+	trace.add(ResolutionEventMoveSucceeded, cause, move, move.Destination)
+
 	destination := board[move.Destination]
 
 	destination.replaceUnit(move.unit())
 	destination.order = nil
 
 	// Seas cannot be controlled, and unconquered castles must be besieged first, unless the
-	// attacking unit is a catapult
+	// attacking unit can capture castles instantly (such as catapults)
 	if !destination.Sea &&
-		(!destination.Castle || destination.controlled() || move.UnitType == UnitCatapult) {
+		(!destination.Castle ||
+			destination.controlled() ||
+			unitTypes[move.UnitType].InstantCastleCapture) {
 		destination.ControllingFaction = move.Faction
 	}
 
@@ -235,7 +259,8 @@ func (board Board) succeedMove(move *Order) {
 	}
 }
 
-func (board Board) killMove(move *Order) {
+func (board Board) killMove(move *Order, trace *resolutionTrace, cause ResolutionCause) {
+	trace.add(ResolutionEventMoveKilled, cause, move, move.Destination)
 	board.removeOrder(move)
 
 	if !move.Retreat {
@@ -247,7 +272,8 @@ func (board Board) killMove(move *Order) {
 	}
 }
 
-func (board Board) retreatMove(move *Order) {
+func (board Board) retreatMove(move *Order, trace *resolutionTrace, cause ResolutionCause) {
+	trace.add(ResolutionEventMoveRetreated, cause, move, move.Destination)
 	board.removeOrder(move)
 
 	if !move.Retreat {
@@ -269,8 +295,34 @@ func (board Board) retreatMove(move *Order) {
 	}
 }
 
-func (board Board) unitCounts(faction PlayerFaction) (unitCount int, maxUnitCount int) {
-	homeRegionsControlled := 0
+// A faction's unit quota for the winter round, telling them how many units they can build or must
+// disband.
+type BuildPlan struct {
+	// The number of units the faction has on the board.
+	UnitCount int
+
+	// The number of units the faction can have: one for each of their controlled home regions, plus
+	// one for each fully controlled nation.
+	MaxUnitCount int
+
+	// The number of build orders the faction can give. 0 if they must disband units.
+	AllowedBuilds int
+
+	// The number of disband orders the faction must give. 0 if they can build units.
+	RequiredDisbands int
+
+	// The faction's home regions that they control, each of which adds 1 to MaxUnitCount.
+	HomeRegionsControlled []RegionName
+
+	// Nations other than the faction's home nation where the faction controls every region, each of
+	// which adds 1 to MaxUnitCount. Unlike [Standing.NationsControlled], the home nation is not
+	// included, as its regions count through HomeRegionsControlled instead.
+	BonusNations []string
+}
+
+func (board Board) buildPlan(faction PlayerFaction) BuildPlan {
+	unitCount := 0
+	homeRegionsControlled := []RegionName{}
 	var nationsControlled set.ArraySet[string]
 	var nationsNotControlled set.ArraySet[string]
 
@@ -281,7 +333,7 @@ func (board Board) unitCounts(faction PlayerFaction) (unitCount int, maxUnitCoun
 
 		if region.HomeFaction == faction {
 			if region.ControllingFaction == faction {
-				homeRegionsControlled++
+				homeRegionsControlled = append(homeRegionsControlled, region.Name)
 			}
 		} else {
 			if region.ControllingFaction == faction {
@@ -295,8 +347,20 @@ func (board Board) unitCounts(faction PlayerFaction) (unitCount int, maxUnitCoun
 		}
 	}
 
-	maxUnitCount = homeRegionsControlled + nationsControlled.Size()
-	return unitCount, maxUnitCount
+	// Sorts regions and nations, so that the plan does not depend on map iteration order
+	slices.Sort(homeRegionsControlled)
+	nations := append(make([]string, 0, nationsControlled.Size()), nationsControlled.ToSlice()...)
+	slices.Sort(nations)
+
+	maxUnitCount := len(homeRegionsControlled) + len(nations)
+	return BuildPlan{
+		UnitCount:             unitCount,
+		MaxUnitCount:          maxUnitCount,
+		AllowedBuilds:         max(maxUnitCount-unitCount, 0),
+		RequiredDisbands:      max(unitCount-maxUnitCount, 0),
+		HomeRegionsControlled: homeRegionsControlled,
+		BonusNations:          nations,
+	}
 }
 
 func (board Board) copy() Board {
@@ -0,0 +1,170 @@
+package game
+
+import (
+	"maps"
+	"slices"
+)
+
+// Changes to the board since the previous diff was sent, so that clients can keep their board in
+// sync without replaying the resolution logic. Sent after each resolution step, and at the end of
+// each round.
+type BoardDiff struct {
+	// Regions whose unit changed, e.g. because a unit moved, was killed, built or disbanded. A move
+	// shows up as two changes: one for the region the unit left, and one for the region it entered.
+	UnitChanges []UnitChange
+
+	// Regions whose controlling faction changed, e.g. after a conquest or a successful siege.
+	ControlChanges []ControlChange
+
+	// Regions whose siege count changed.
+	SiegeCountChanges []SiegeCountChange
+}
+
+type UnitChange struct {
+	Region RegionName
+
+	// Nil if the region had no unit.
+	Before *Unit
+
+	// Nil if the region no longer has a unit.
+	After *Unit
+}
+
+type ControlChange struct {
+	Region RegionName
+
+	// Blank if the region was uncontrolled.
+	Before PlayerFaction
+
+	// Blank if the region is now uncontrolled.
+	After PlayerFaction
+}
+
+type SiegeCountChange struct {
+	Region RegionName
+	Before int
+	After  int
+}
+
+// Returns the changes from the previous board to the current board. Assumes that both boards have
+// the same regions.
+func diffBoards(previous Board, current Board) BoardDiff {
+	diff := BoardDiff{
+		UnitChanges:       []UnitChange{},
+		ControlChanges:    []ControlChange{},
+		SiegeCountChanges: []SiegeCountChange{},
+	}
+
+	for _, regionName := range slices.Sorted(maps.Keys(current)) {
+		before, after := previous[regionName], current[regionName]
+
+		if !unitsEqual(before.Unit, after.Unit) {
+			diff.UnitChanges = append(
+				diff.UnitChanges,
+				UnitChange{
+					Region: regionName,
+					Before: copyUnit(before.Unit),
+					After:  copyUnit(after.Unit),
+				},
+			)
+		}
+
+		if before.ControllingFaction != after.ControllingFaction {
+			diff.ControlChanges = append(
+				diff.ControlChanges,
+				ControlChange{
+					Region: regionName,
+					Before: before.ControllingFaction,
+					After:  after.ControllingFaction,
+				},
+			)
+		}
+
+		if before.SiegeCount != after.SiegeCount {
+			diff.SiegeCountChanges = append(
+				diff.SiegeCountChanges,
+				SiegeCountChange{
+					Region: regionName,
+					Before: before.SiegeCount,
+					After:  after.SiegeCount,
+				},
+			)
+		}
+	}
+
+	return diff
+}
+
+// Returns a copy of the board as it was before the given diff, assuming that the board is the
+// result of the diff.
+func (board Board) beforeDiff(diff BoardDiff) Board {
+	previous := board.snapshot()
+	for _, change := range diff.UnitChanges {
+		previous[change.Region].Unit = copyUnit(change.Before)
+	}
+	for _, change := range diff.ControlChanges {
+		previous[change.Region].ControllingFaction = change.Before
+	}
+	for _, change := range diff.SiegeCountChanges {
+		previous[change.Region].SiegeCount = change.Before
+	}
+	return previous
+}
+
+// Returns whether the diff has no changes.
+func (diff BoardDiff) Empty() bool {
+	return len(diff.UnitChanges) == 0 &&
+		len(diff.ControlChanges) == 0 &&
+		len(diff.SiegeCountChanges) == 0
+}
+
+// Returns a copy of the board that shares no mutable state with the original, and has no
+// resolving state. Safe to read while the original board is being resolved.
+func (board Board) snapshot() Board {
+	snapshot := make(Board, len(board))
+	for regionName, region := range board {
+		regionCopy := *region
+		regionCopy.Unit = copyUnit(region.Unit)
+		regionCopy.regionResolvingState = regionResolvingState{} //nolint:exhaustruct // Reset
+		snapshot[regionName] = &regionCopy
+	}
+	return snapshot
+}
+
+// Computes the changes to the board since the last snapshot, and sends them to all players if there
+// are any. Then takes a new snapshot, which is returned by [Game.BoardSnapshot].
+func (game *Game) sendBoardDiff() {
+	game.snapshotLock.Lock()
+	diff := diffBoards(game.boardSnapshot, game.board)
+	game.boardSnapshot = game.board.snapshot()
+	game.snapshotSeason = game.season
+	game.snapshotLock.Unlock()
+
+	if !diff.Empty() {
+		game.messenger.SendBoardDiff(diff)
+	}
+}
+
+// Returns a copy of the board as of the last [BoardDiff] sent to players, along with the season at
+// that point. Safe to call while the game is running, e.g. for players that want to resync their
+// board. The returned board must not be modified.
+func (game *Game) BoardSnapshot() (Board, Season) {
+	game.snapshotLock.RLock()
+	defer game.snapshotLock.RUnlock()
+
+	return game.boardSnapshot, game.snapshotSeason
+}
+
+func unitsEqual(unit1 *Unit, unit2 *Unit) bool {
+	if unit1 == nil || unit2 == nil {
+		return unit1 == unit2
+	}
+	return *unit1 == *unit2
+}
+
+func copyUnit(unit *Unit) *Unit {
+	if unit == nil {
+		return nil
+	}
+	return ptr(*unit)
+}
@@ -22,6 +22,7 @@ type boardConfig struct {
 	Nations            map[string][]landRegionConfig `json:"nations"`
 	Seas               []seaRegionConfig             `json:"seas"`
 	Neighbors          []neighborConfig              `json:"neighbors"`
+	UnitTypes          []unitTypeConfig              `json:"unitTypes"`
 }
 
 type landRegionConfig struct {
@@ -43,6 +44,20 @@ type neighborConfig struct {
 	DangerZone string `json:"dangerZone"`
 }
 
+// Adds a unit type to the board, or overrides the properties of a built-in unit type (see
+// [UnitTypeProperties]).
+type unitTypeConfig struct {
+	Type                 UnitType `json:"type"`
+	Name                 string   `json:"name"`
+	Naval                bool     `json:"naval"`
+	MoveRange            int      `json:"moveRange"` // Defaults to 1 if omitted.
+	AttackModifier       int      `json:"attackModifier"`
+	DefenseModifier      int      `json:"defenseModifier"`
+	CastleAttackModifier int      `json:"castleAttackModifier"`
+	InstantCastleCapture bool     `json:"instantCastleCapture"`
+	BuildCost            int      `json:"buildCost"`
+}
+
 func ReadBoardFromConfigFile(boardID string) (Board, BoardInfo, error) {
 	content, err := boardConfigFiles.ReadFile("boardconfig/" + boardID + ".json")
 	if err != nil {
@@ -64,6 +79,11 @@ func ReadBoardFromConfigFile(boardID string) (Board, BoardInfo, error) {
 		)
 	}
 
+	unitTypes, err := parseUnitTypes(config.UnitTypes)
+	if err != nil {
+		return Board{}, BoardInfo{}, wrap.Error(err, "invalid unitTypes in board config")
+	}
+
 	board := make(Board)
 	var factions set.ArraySet[PlayerFaction]
 
@@ -154,6 +174,7 @@ func ReadBoardFromConfigFile(boardID string) (Board, BoardInfo, error) {
 		Name:               config.Name,
 		WinningCastleCount: config.WinningCastleCount,
 		PlayerFactions:     factions.ToSlice(),
+		UnitTypes:          unitTypes,
 	}
 	slices.Sort(boardInfo.PlayerFactions)
 
@@ -166,6 +187,7 @@ type partialBoardConfig struct {
 	Nations            map[string][]struct {
 		HomeFaction string `json:"homeFaction"`
 	} `json:"nations"`
+	UnitTypes []unitTypeConfig `json:"unitTypes"`
 }
 
 func GetAvailableBoards() ([]BoardInfo, error) {
@@ -211,11 +233,17 @@ func GetAvailableBoards() ([]BoardInfo, error) {
 					)
 				}
 
+				unitTypes, err := parseUnitTypes(board.UnitTypes)
+				if err != nil {
+					return wrap.Errorf(err, "invalid unitTypes in board config file '%s'", fullName)
+				}
+
 				boardInfo := BoardInfo{
 					ID:                 baseName,
 					Name:               board.Name,
 					WinningCastleCount: board.WinningCastleCount,
 					PlayerFactions:     factions.ToSlice(),
+					UnitTypes:          unitTypes,
 				}
 				slices.Sort(boardInfo.PlayerFactions)
 
@@ -231,3 +259,43 @@ func GetAvailableBoards() ([]BoardInfo, error) {
 
 	return availableBoards, nil
 }
+
+// Returns the built-in unit types, along with the unit types from the given board config (which
+// may override the built-in ones).
+func parseUnitTypes(configs []unitTypeConfig) (UnitTypes, error) {
+	unitTypes := defaultUnitTypes()
+
+	for _, config := range configs {
+		if config.Type == 0 {
+			return nil, fmt.Errorf("missing type for unit type '%s'", config.Name)
+		}
+		if config.Name == "" {
+			return nil, fmt.Errorf("missing name for unit type %d", config.Type)
+		}
+
+		moveRange := config.MoveRange
+		if moveRange == 0 {
+			moveRange = 1
+		}
+		if moveRange != 1 && moveRange != 2 {
+			return nil, fmt.Errorf(
+				"invalid moveRange %d for unit type '%s' (must be 1 or 2)",
+				moveRange,
+				config.Name,
+			)
+		}
+
+		unitTypes[config.Type] = UnitTypeProperties{
+			Name:                 config.Name,
+			Naval:                config.Naval,
+			MoveRange:            moveRange,
+			AttackModifier:       config.AttackModifier,
+			DefenseModifier:      config.DefenseModifier,
+			CastleAttackModifier: config.CastleAttackModifier,
+			InstantCastleCapture: config.InstantCastleCapture,
+			BuildCost:            config.BuildCost,
+		}
+	}
+
+	return unitTypes, nil
+}
@@ -42,8 +42,16 @@ func (board Board) findBorderBattle(region *Region) (isBorderBattle bool, second
 	return false, nil
 }
 
-func (cycle MoveCycle) prepareForResolving() {
+func (cycle MoveCycle) prepareForResolving(trace *resolutionTrace) {
 	for _, region := range cycle {
+		if region.order != nil {
+			trace.add(
+				ResolutionEventMoveCycleFound,
+				ResolutionCauseMoveCycle,
+				region.order,
+				region.Name,
+			)
+		}
 		region.removeUnit()
 		region.order = nil
 		region.partOfCycle = true
@@ -1,5 +1,7 @@
 package game
 
+import "context"
+
 type DangerZone string
 
 // Number to beat when attempting to cross a danger zone.
@@ -18,7 +20,7 @@ func newDangerZoneCrossing(order *Order, dangerZone DangerZone) Battle {
 	}
 }
 
-func (game *Game) resolveDangerZoneCrossings(region *Region) {
+func (game *Game) resolveDangerZoneCrossings(ctx context.Context, region *Region) {
 	if region.dangerZonesResolved {
 		return
 	}
@@ -26,7 +28,7 @@ func (game *Game) resolveDangerZoneCrossings(region *Region) {
 	for _, orders := range [...][]*Order{region.incomingMoves, region.incomingSupports} {
 		for _, order := range orders {
 			if mustCross, dangerZone := order.mustCrossDangerZone(region); mustCross {
-				game.resolveDangerZoneCrossing(newDangerZoneCrossing(order, dangerZone))
+				game.resolveDangerZoneCrossing(ctx, newDangerZoneCrossing(order, dangerZone))
 			}
 		}
 	}
@@ -34,24 +36,32 @@ func (game *Game) resolveDangerZoneCrossings(region *Region) {
 	region.dangerZonesResolved = true
 }
 
-func (game *Game) resolveDangerZoneCrossing(crossing Battle) {
+func (game *Game) resolveDangerZoneCrossing(ctx context.Context, crossing Battle) {
 	order := crossing.Results[0].Order
 
+	battlesMetric.Inc(battleKindDangerZone)
 	game.messenger.SendBattleAnnouncement(crossing)
 
-	ctx, cleanup := newPlayerInputContext()
+	ctx, cleanup := newPlayerInputContext(ctx)
 	defer cleanup()
 
 	if err := game.messenger.AwaitDiceRoll(ctx, order.Faction); err != nil {
-		game.log.Error(nil, err, "")
+		err = handleTimeout(err)
+		game.log.Error(ctx, err, "")
 	}
 
 	crossing.addModifier(order.Faction, newModifier(ModifierDice, game.rollDice()))
 
 	if crossing.Results[0].Total < MinResultToSurviveDangerZone {
 		if order.Type == OrderMove {
-			game.board.killMove(order)
+			game.board.killMove(order, &game.trace, ResolutionCauseDangerZone)
 		} else {
+			game.trace.add(
+				ResolutionEventSupportCut,
+				ResolutionCauseDangerZone,
+				order,
+				order.Origin,
+			)
 			game.board.removeOrder(order)
 		}
 	}
@@ -0,0 +1,63 @@
+package game
+
+// Income that each controlled region gives its faction at the start of every round, in games with
+// the economy rule (see [Rules]). Castle regions give castleIncome on top of regionIncome.
+const (
+	regionIncome = 1
+	castleIncome = 1
+)
+
+// Returns the income that each faction earns per round from the regions it controls, in games with
+// the economy rule.
+func (board Board) income() map[PlayerFaction]int {
+	income := make(map[PlayerFaction]int)
+	for _, region := range board {
+		if !region.controlled() {
+			continue
+		}
+
+		income[region.ControllingFaction] += regionIncome
+		if region.Castle {
+			income[region.ControllingFaction] += castleIncome
+		}
+	}
+	return income
+}
+
+// Adds each faction's income to its treasury, if the game uses the economy rule.
+func (game *Game) collectIncome() {
+	if !game.rules.Economy {
+		return
+	}
+
+	for faction, income := range game.board.income() {
+		game.treasury[faction] += income
+	}
+}
+
+// Subtracts the cost of the given build order from its faction's treasury, if the game uses the
+// economy rule.
+func (game *Game) payForBuild(build *Order) {
+	if game.rules.Economy {
+		game.treasury[build.Faction] -= game.UnitTypes[build.UnitType].BuildCost
+	}
+}
+
+// Returns the given faction's treasury, or nil if the game does not use the economy rule.
+func (game *Game) treasuryOf(faction PlayerFaction) *int {
+	if !game.rules.Economy {
+		return nil
+	}
+	return ptr(game.treasury[faction])
+}
+
+// Returns the total cost of the build orders among the given orders.
+func buildCost(orders []*Order, unitTypes UnitTypes) int {
+	cost := 0
+	for _, order := range orders {
+		if order.Type == OrderBuild {
+			cost += unitTypes[order.UnitType].BuildCost
+		}
+	}
+	return cost
+}
@@ -0,0 +1,245 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"hermannm.dev/enumnames"
+)
+
+// An error caused by invalid input from a player, with structured details that are sent to the
+// client alongside the error message. Use [errors.As] to get it from a wrapped error chain.
+type InputError struct {
+	Details ErrorDetails
+	err     error
+}
+
+// Structured details about an error, letting clients show a translated message and highlight the
+// cause of the error.
+type ErrorDetails struct {
+	Code ErrorCode
+
+	// Index of the offending order in the submitted order set. Nil if the error is not caused by a
+	// single order.
+	OrderIndex *int
+
+	// The region that caused the error, if any. For errors caused by an order, this defaults to
+	// the order's origin region.
+	Region RegionName
+
+	// For errors caused by a mismatch: the value that was expected, and the value that was
+	// received. Blank if not applicable.
+	Expected string
+	Actual   string
+}
+
+func newInputError(code ErrorCode, err error) *InputError {
+	return &InputError{
+		Details: ErrorDetails{Code: code, OrderIndex: nil, Region: "", Expected: "", Actual: ""},
+		err:     err,
+	}
+}
+
+func (err *InputError) Error() string {
+	return err.err.Error()
+}
+
+func (err *InputError) Unwrap() error {
+	return err.err
+}
+
+// Sets the expected and actual values in the error's details, and returns the error.
+func (err *InputError) withMismatch(expected string, actual string) *InputError {
+	err.Details.Expected = expected
+	err.Details.Actual = actual
+	return err
+}
+
+// Sets the region in the error's details, and returns the error.
+func (err *InputError) withRegion(region RegionName) *InputError {
+	err.Details.Region = region
+	return err
+}
+
+// If the given error chain contains an InputError, ties it to the order at the given index in the
+// submitted order set. The order's origin is used as the error's region, unless a region is
+// already set.
+func withOrderDetails(err error, orderIndex int, order *Order) error {
+	var inputErr *InputError
+	if errors.As(err, &inputErr) {
+		inputErr.Details.OrderIndex = &orderIndex
+		if inputErr.Details.Region == "" {
+			inputErr.Details.Region = order.Origin
+		}
+	}
+	return err
+}
+
+// All errors found when validating a player's submitted order set. Each error chain contains an
+// [InputError], which is tied to the offending order unless the error concerns the order set as a
+// whole (such as the number of build orders). Use [errors.As] to get it from a wrapped error chain.
+type OrderValidationErrors []error
+
+func (errs OrderValidationErrors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "found %d errors in orders:", len(errs))
+	for _, err := range errs {
+		builder.WriteString("\n- ")
+		builder.WriteString(err.Error())
+	}
+	return builder.String()
+}
+
+func (errs OrderValidationErrors) Unwrap() []error {
+	return errs
+}
+
+// Returns the code of the InputError in the given error chain, or ErrorCodeUnknown if there is
+// none.
+func errorCode(err error) ErrorCode {
+	var inputErr *InputError
+	if errors.As(err, &inputErr) {
+		return inputErr.Details.Code
+	}
+	return ErrorCodeUnknown
+}
+
+// Identifies the kind of an [InputError].
+//
+// Code values are part of the protocol between client and server, so they must never change once
+// released. New codes must be given a new, unused number.
+type ErrorCode uint8
+
+const (
+	// An error that has not been categorized.
+	ErrorCodeUnknown ErrorCode = 1
+
+	// An order referred to a region that does not exist on the board.
+	ErrorCodeUnknownRegion ErrorCode = 2
+
+	// The order type is not valid, or not allowed in the current season.
+	ErrorCodeInvalidOrderType ErrorCode = 3
+
+	// The unit type is not valid, or not allowed for the order.
+	ErrorCodeInvalidUnitType ErrorCode = 4
+
+	// The ordered region does not have a unit.
+	ErrorCodeNoUnitInRegion ErrorCode = 5
+
+	// The unit in the ordered region belongs to a different faction than the player's.
+	ErrorCodeFactionMismatch ErrorCode = 6
+
+	// The unit type of the order does not match the unit in the ordered region.
+	ErrorCodeUnitTypeMismatch ErrorCode = 7
+
+	// The order was submitted as a retreat, which only the server can create.
+	ErrorCodeRetreatNotAllowed ErrorCode = 8
+
+	// The order requires a destination, but none was given.
+	ErrorCodeMissingDestination ErrorCode = 9
+
+	// The order type cannot have a destination, but one was given.
+	ErrorCodeUnexpectedDestination ErrorCode = 10
+
+	// The destination region is not valid for the ordered unit, e.g. a land unit ordered to sea.
+	ErrorCodeInvalidDestination ErrorCode = 11
+
+	// The order's destination must be adjacent to its origin, but is not.
+	ErrorCodeNotAdjacent ErrorCode = 12
+
+	// A second destination was given for a unit that is not a knight.
+	ErrorCodeSecondDestinationNotAllowed ErrorCode = 13
+
+	// The ordered region cannot be besieged, or the unit cannot besiege.
+	ErrorCodeInvalidBesiege ErrorCode = 14
+
+	// The ordered unit cannot transport from its region.
+	ErrorCodeInvalidTransport ErrorCode = 15
+
+	// The destination of a winter move is not controlled by the player.
+	ErrorCodeRegionNotControlled ErrorCode = 16
+
+	// The region already has a unit, so it cannot be built in or moved to.
+	ErrorCodeRegionOccupied ErrorCode = 17
+
+	// Two move orders have the same destination.
+	ErrorCodeDuplicateMoveDestination ErrorCode = 18
+
+	// The same region was ordered twice.
+	ErrorCodeDuplicateOrder ErrorCode = 19
+
+	// The number of build orders does not match the number of units the player can build.
+	ErrorCodeInvalidBuildCount ErrorCode = 20
+
+	// The number of disband orders does not match the number of units the player must disband.
+	ErrorCodeInvalidDisbandCount ErrorCode = 21
+
+	// The move destination is not adjacent, and there is no transport path to it.
+	ErrorCodeUnreachableDestination ErrorCode = 22
+
+	// The player did not respond in time.
+	ErrorCodeTimedOut ErrorCode = 23
+
+	// The player chose to support a faction that is not in the battle.
+	ErrorCodeInvalidSupportedFaction ErrorCode = 24
+
+	// The player tried to edit or remove an order draft index that does not exist.
+	ErrorCodeInvalidDraftIndex ErrorCode = 25
+
+	// The player chose a retreat destination that their unit cannot retreat to.
+	ErrorCodeInvalidRetreatDestination ErrorCode = 26
+
+	// The move's transport path is not a chain of adjacent transporting ships from the move's
+	// origin to its destination.
+	ErrorCodeInvalidTransportPath ErrorCode = 27
+
+	// The total cost of the player's build orders is more than they have in their treasury.
+	ErrorCodeInsufficientFunds ErrorCode = 28
+)
+
+var errorCodeNames = enumnames.NewMap(
+	map[ErrorCode]string{
+		ErrorCodeUnknown:                     "Unknown",
+		ErrorCodeUnknownRegion:               "UnknownRegion",
+		ErrorCodeInvalidOrderType:            "InvalidOrderType",
+		ErrorCodeInvalidUnitType:             "InvalidUnitType",
+		ErrorCodeNoUnitInRegion:              "NoUnitInRegion",
+		ErrorCodeFactionMismatch:             "FactionMismatch",
+		ErrorCodeUnitTypeMismatch:            "UnitTypeMismatch",
+		ErrorCodeRetreatNotAllowed:           "RetreatNotAllowed",
+		ErrorCodeMissingDestination:          "MissingDestination",
+		ErrorCodeUnexpectedDestination:       "UnexpectedDestination",
+		ErrorCodeInvalidDestination:          "InvalidDestination",
+		ErrorCodeNotAdjacent:                 "NotAdjacent",
+		ErrorCodeSecondDestinationNotAllowed: "SecondDestinationNotAllowed",
+		ErrorCodeInvalidBesiege:              "InvalidBesiege",
+		ErrorCodeInvalidTransport:            "InvalidTransport",
+		ErrorCodeRegionNotControlled:         "RegionNotControlled",
+		ErrorCodeRegionOccupied:              "RegionOccupied",
+		ErrorCodeDuplicateMoveDestination:    "DuplicateMoveDestination",
+		ErrorCodeDuplicateOrder:              "DuplicateOrder",
+		ErrorCodeInvalidBuildCount:           "InvalidBuildCount",
+		ErrorCodeInvalidDisbandCount:         "InvalidDisbandCount",
+		ErrorCodeUnreachableDestination:      "UnreachableDestination",
+		ErrorCodeTimedOut:                    "TimedOut",
+		ErrorCodeInvalidSupportedFaction:     "InvalidSupportedFaction",
+		ErrorCodeInvalidDraftIndex:           "InvalidDraftIndex",
+		ErrorCodeInvalidRetreatDestination:   "InvalidRetreatDestination",
+		ErrorCodeInvalidTransportPath:        "InvalidTransportPath",
+		ErrorCodeInsufficientFunds:           "InsufficientFunds",
+	},
+)
+
+func (code ErrorCode) String() string {
+	return errorCodeNames.GetNameOrFallback(code, "INVALID")
+}
+
+// Returns all valid error codes (used for generating protocol schemas).
+func (ErrorCode) Values() []ErrorCode {
+	return errorCodeNames.Keys()
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"slices"
+
+	"hermannm.dev/set"
+)
+
+// The part of the board that a faction can see in games with fog of war: regions where the faction
+// has units or control, along with their neighbors. The filter methods on Visibility hide game
+// state outside of those regions.
+//
+// A nil Visibility sees the whole board, so that callers can use the same code for games with and
+// without fog of war.
+type Visibility struct {
+	faction PlayerFaction
+	regions set.HashSet[RegionName]
+}
+
+// Returns the regions that the given faction can currently see. Must only be called from the
+// game's own goroutine (i.e. from [Messenger] methods called by the game), since the board may
+// otherwise be in the middle of resolving.
+func (game *Game) Visibility(faction PlayerFaction) *Visibility {
+	return game.board.VisibilityFor(faction)
+}
+
+// Returns the regions on the board that the given faction can see.
+func (board Board) VisibilityFor(faction PlayerFaction) *Visibility {
+	regions := set.NewHashSet[RegionName]()
+
+	for _, region := range board {
+		hasUnit := !region.empty() && region.Unit.Faction == faction
+		if !hasUnit && region.ControllingFaction != faction {
+			continue
+		}
+
+		regions.Add(region.Name)
+		for _, neighbor := range region.Neighbors {
+			regions.Add(neighbor.Name)
+		}
+	}
+
+	return &Visibility{faction: faction, regions: regions}
+}
+
+// Returns whether the given region is visible.
+func (visibility *Visibility) CanSee(region RegionName) bool {
+	return visibility == nil || visibility.regions.Contains(region)
+}
+
+// Returns a copy of the board, where units, control and sieges are hidden in regions that are not
+// visible. Returns the board itself if the visibility is nil.
+func (visibility *Visibility) FilterBoard(board Board) Board {
+	if visibility == nil {
+		return board
+	}
+
+	filtered := make(Board, len(board))
+	for regionName, region := range board {
+		if visibility.CanSee(regionName) {
+			filtered[regionName] = region
+			continue
+		}
+
+		regionCopy := *region
+		regionCopy.Unit = nil
+		regionCopy.ControllingFaction = ""
+		regionCopy.SiegeCount = 0
+		filtered[regionName] = &regionCopy
+	}
+	return filtered
+}
+
+// Returns the orders that either start or end in a visible region.
+func (visibility *Visibility) FilterOrders(orders []*Order) []*Order {
+	if visibility == nil {
+		return orders
+	}
+
+	return slices.DeleteFunc(slices.Clone(orders), func(order *Order) bool {
+		return !visibility.CanSee(order.Origin) && !visibility.CanSee(order.Destination)
+	})
+}
+
+// Returns whether the battle takes place in a visible region, or the visibility's faction fights
+// in it.
+func (visibility *Visibility) CanSeeBattle(battle Battle) bool {
+	if visibility == nil || visibility.faction.isFighting(&battle) {
+		return true
+	}
+
+	return slices.ContainsFunc(battle.RegionNames(), visibility.CanSee)
+}
+
+// Returns the changes in the diff as the visibility's faction should see them, given the board
+// after the diff was applied.
+//
+// Changes in regions that are not visible are left out. The faction's visibility may also change
+// with the diff: regions that became visible are included with their full state (even if they did
+// not change), and regions that became hidden are cleared like in FilterBoard. This keeps the
+// faction's board in sync with what it can see. The previous visibility is found from the board
+// before the diff, which is the board that the faction last received.
+func (visibility *Visibility) FilterBoardDiff(diff BoardDiff, board Board) BoardDiff {
+	if visibility == nil {
+		return diff
+	}
+
+	previousBoard := board.beforeDiff(diff)
+	previous := previousBoard.VisibilityFor(visibility.faction)
+	return diffBoards(previous.FilterBoard(previousBoard), visibility.FilterBoard(board))
+}
+
+// Returns the standings with other factions' unit counts, region counts, nations and economy
+// hidden (see [Standing.Hidden]). Castle counts are always shown, since every player needs to know
+// how close the others are to winning.
+func (visibility *Visibility) FilterStandings(standings []Standing) []Standing {
+	if visibility == nil {
+		return standings
+	}
+
+	filtered := make([]Standing, 0, len(standings))
+	for _, standing := range standings {
+		if standing.Faction != visibility.faction {
+			standing = Standing{
+				Faction:           standing.Faction,
+				CastleCount:       standing.CastleCount,
+				RegionCount:       0,
+				UnitCount:         0,
+				NationsControlled: []string{},
+				CastlesToWin:      standing.CastlesToWin,
+				Income:            0,
+				Treasury:          0,
+				Hidden:            true,
+			}
+		}
+		filtered = append(filtered, standing)
+	}
+	return filtered
+}
+
+// Returns the resolution events that take place in visible regions, or concern orders from
+// visible regions.
+func (visibility *Visibility) FilterResolutionEvents(events []ResolutionEvent) []ResolutionEvent {
+	if visibility == nil {
+		return events
+	}
+
+	return slices.DeleteFunc(slices.Clone(events), func(event ResolutionEvent) bool {
+		if visibility.CanSee(event.Region) {
+			return false
+		}
+		return event.Order == nil || !visibility.CanSee(event.Order.Origin)
+	})
+}
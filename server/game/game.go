@@ -2,20 +2,54 @@ package game
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"math/rand/v2"
+	"sync"
 	"time"
 
 	"hermannm.dev/devlog/log"
+	"hermannm.dev/wrap"
 )
 
 type Game struct {
 	BoardInfo
+	rules     Rules
 	board     Board
 	season    Season
 	messenger Messenger
 	log       log.Logger
 	rollDice  func() int
+
+	// Events from resolving the current round, sent to players as a round report.
+	trace resolutionTrace
+
+	// Money that each faction has left to build units with. Only used with the economy rule (see
+	// [Rules]).
+	treasury map[PlayerFaction]int
+
+	// Moves that lost or tied a battle in the current round, whose units are waiting for their
+	// players to choose where to retreat. Only used with the retreat choice rule (see [Rules]).
+	pendingRetreats []*Order
+
+	// Set by StopAfterCurrentStep. Must hold stopLock to access safely.
+	stopCause error
+	// Non-nil while gathering orders. Must hold stopLock to access safely.
+	cancelOrderGathering context.CancelCauseFunc
+	stopLock             sync.Mutex
+
+	// Copy of the board as of the last BoardDiff sent to players, and the season at that point.
+	// Must hold snapshotLock to access safely.
+	boardSnapshot  Board
+	snapshotSeason Season
+	snapshotLock   sync.RWMutex
+
+	// Order drafts of the players that the game is currently gathering orders from. Must hold
+	// draftsLock to access safely, both the map and the drafts in it.
+	drafts     map[PlayerFaction]*orderDraft
+	draftsLock sync.RWMutex
 }
 
 type BoardInfo struct {
@@ -23,6 +57,9 @@ type BoardInfo struct {
 	Name               string
 	WinningCastleCount int
 	PlayerFactions     []PlayerFaction
+
+	// The unit types that can be used on the board, including the built-in ones.
+	UnitTypes UnitTypes
 }
 
 // A faction on the board (e.g. green/red/yellow units) controlled by a player.
@@ -31,25 +68,43 @@ type PlayerFaction string
 type Messenger interface {
 	SendError(to PlayerFaction, err error)
 	SendGameStarted(board Board)
-	SendOrderRequest(to PlayerFaction, season Season) (succeeded bool)
+	SendOrderRequest(
+		to PlayerFaction,
+		season Season,
+		draft []*Order,
+		buildPlan *BuildPlan,
+	) (succeeded bool)
+	SendOrderDraft(to PlayerFaction, draft []*Order, errs OrderValidationErrors)
 	SendOrdersConfirmation(factionThatSubmittedOrders PlayerFaction)
+	SendOrdersRetracted(factionThatRetractedOrders PlayerFaction)
 	SendOrdersReceived(orders map[PlayerFaction][]*Order)
+	SendLegalOrders(to PlayerFaction, region RegionName, orders []*Order)
 	SendBattleAnnouncement(battle Battle)
 	SendBattleResults(battle Battle)
+	SendBoardDiff(diff BoardDiff)
+	SendRoundReport(season Season, events []ResolutionEvent)
+	SendStandings(season Season, standings []Standing)
 	SendWinner(winner PlayerFaction)
-	AwaitOrders(ctx context.Context, from PlayerFaction) ([]*Order, error)
+	SendRetreatRequest(to PlayerFaction, request RetreatRequest)
+	AwaitOrderInput(ctx context.Context, from PlayerFaction) (OrderInput, error)
 	AwaitDiceRoll(ctx context.Context, from PlayerFaction) error
 	AwaitSupport(
 		ctx context.Context,
 		from PlayerFaction,
 		embattledRegion RegionName,
 	) (supported PlayerFaction, err error)
+	AwaitRetreat(
+		ctx context.Context,
+		from PlayerFaction,
+		retreatingFrom RegionName,
+	) (destination RegionName, err error)
 	ClearMessages()
 }
 
 func New(
 	board Board,
 	boardInfo BoardInfo,
+	rules Rules,
 	messenger Messenger,
 	logger log.Logger,
 	customDiceRoller func() int,
@@ -57,11 +112,29 @@ func New(
 	game := Game{
 		board:     board,
 		BoardInfo: boardInfo,
+		rules:     rules,
 		season:    SeasonWinter,
 		messenger: messenger,
 		log:       logger,
 		rollDice:  customDiceRoller,
+		trace:     nil,
+
+		treasury:        make(map[PlayerFaction]int),
+		pendingRetreats: nil,
+
+		stopCause:            nil,
+		cancelOrderGathering: nil,
+		stopLock:             sync.Mutex{},
+
+		boardSnapshot:  board.snapshot(),
+		snapshotSeason: SeasonWinter,
+		snapshotLock:   sync.RWMutex{},
+
+		drafts:     make(map[PlayerFaction]*orderDraft),
+		draftsLock: sync.RWMutex{},
 	}
+	game.collectIncome() // Income for the first round, as later rounds get theirs in Run
+
 	if game.rollDice == nil {
 		game.rollDice = func() int {
 			return rand.IntN(6) + 1 //nolint:gosec // Acceptable to use non-crypto randomness here
@@ -71,31 +144,141 @@ func New(
 	return &game
 }
 
-func (game *Game) Run() {
+// Returned from [Game.Run] when the game was stopped before a player faction won.
+var ErrGameAborted = errors.New("game aborted")
+
+// Runs the game until a player faction wins, or the given context is canceled. In the latter case,
+// returns an error wrapping [ErrGameAborted] and the context's cancel cause. The same goes if
+// [Game.StopAfterCurrentStep] is called.
+func (game *Game) Run(ctx context.Context) error {
 	game.messenger.SendGameStarted(game.board)
 
 	for {
-		orders := game.gatherAndValidateOrders()
+		orders := game.gatherOrdersUnlessStopped(ctx)
+		if ctx.Err() != nil {
+			return abortedError(ctx)
+		}
+		if stopCause := game.stopRequested(); stopCause != nil {
+			return fmt.Errorf("%w: %w", ErrGameAborted, stopCause)
+		}
 
 		if game.season == SeasonWinter {
 			game.resolveWinterOrders(orders)
 		} else {
-			game.resolveNonWinterOrders(orders)
-
+			game.resolveNonWinterOrders(ctx, orders)
+			if ctx.Err() != nil {
+				return abortedError(ctx)
+			}
+		}
+		roundsResolvedMetric.Inc()
+		game.sendBoardDiff()
+		game.messenger.SendRoundReport(game.season, game.trace)
+
+		standings := game.standings()
+		game.log.Info(
+			ctx,
+			"Round resolved",
+			"season", game.season,
+			"report", game.trace,
+			"standings", standings,
+		)
+		game.messenger.SendStandings(game.season, standings)
+
+		if game.season != SeasonWinter {
 			if winner := game.checkWinner(); winner != "" {
 				game.messenger.SendWinner(winner)
-				break
+				return nil
 			}
 		}
 
+		// Collects the next round's income before moving on to it, instead of at the start of
+		// the round, so that a game saved between rounds (after StopAfterCurrentStep) has the
+		// treasury for its saved season, and does not collect the same income again when resumed
+		game.collectIncome()
 		game.nextRound()
 	}
 }
 
+func abortedError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrGameAborted, context.Cause(ctx))
+}
+
+// Makes [Game.Run] return with the given cause once the current resolution step is finished, so
+// that the board is left in a consistent state. If the game is currently waiting for orders, it
+// stops waiting immediately.
+func (game *Game) StopAfterCurrentStep(cause error) {
+	game.stopLock.Lock()
+	defer game.stopLock.Unlock()
+
+	game.stopCause = cause
+	if game.cancelOrderGathering != nil {
+		game.cancelOrderGathering(cause)
+	}
+}
+
+func (game *Game) stopRequested() (stopCause error) {
+	game.stopLock.Lock()
+	defer game.stopLock.Unlock()
+
+	return game.stopCause
+}
+
+// Gathers orders with a context that is canceled by [Game.StopAfterCurrentStep]. Returns nil if
+// the game has already been stopped.
+func (game *Game) gatherOrdersUnlessStopped(ctx context.Context) []*Order {
+	game.stopLock.Lock()
+	if game.stopCause != nil {
+		game.stopLock.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	game.cancelOrderGathering = cancel
+	game.stopLock.Unlock()
+
+	orders := game.gatherAndValidateOrders(ctx)
+
+	game.stopLock.Lock()
+	game.cancelOrderGathering = nil
+	game.stopLock.Unlock()
+	cancel(nil)
+
+	return orders
+}
+
+// Game state that can be saved to disk, e.g. when the server shuts down.
+type SavedGame struct {
+	BoardInfo BoardInfo
+	Season    Season
+	Board     Board
+
+	// Each faction's treasury, in games with the economy rule (see [Rules]).
+	Treasury map[PlayerFaction]int `json:",omitempty"`
+}
+
+// Writes the game's current state as JSON to the given writer. Should only be called when the
+// game is not running.
+func (game *Game) Save(writer io.Writer) error {
+	savedGame := SavedGame{
+		BoardInfo: game.BoardInfo,
+		Season:    game.season,
+		Board:     game.board,
+		Treasury:  nil,
+	}
+	if game.rules.Economy {
+		savedGame.Treasury = game.treasury
+	}
+	if err := json.NewEncoder(writer).Encode(savedGame); err != nil {
+		return wrap.Error(err, "failed to serialize game state")
+	}
+	return nil
+}
+
 func (game *Game) nextRound() {
 	game.season = game.season.next()
 	game.messenger.ClearMessages()
 	game.board.resetResolvingState()
+	game.trace = nil
+	game.pendingRetreats = nil
 }
 
 func (game *Game) resolveWinterOrders(orders []*Order) {
@@ -109,9 +292,22 @@ func (game *Game) resolveWinterOrders(orders []*Order) {
 			if region.order != nil {
 				switch region.order.Type {
 				case OrderBuild:
+					game.trace.add(
+						ResolutionEventUnitBuilt,
+						ResolutionCauseUncontested,
+						region.order,
+						region.Name,
+					)
 					region.Unit = &Unit{Faction: region.order.Faction, Type: region.order.UnitType}
+					game.payForBuild(region.order)
 					region.order = nil
 				case OrderDisband:
+					game.trace.add(
+						ResolutionEventUnitDisbanded,
+						ResolutionCauseUncontested,
+						region.order,
+						region.Name,
+					)
 					region.removeUnit()
 					region.order = nil
 				default: // Done
@@ -120,7 +316,7 @@ func (game *Game) resolveWinterOrders(orders []*Order) {
 
 			if !region.partOfCycle {
 				if cycle := game.board.findCycle(region.Name, region); cycle != nil {
-					cycle.prepareForResolving()
+					cycle.prepareForResolving(&game.trace)
 				}
 			}
 
@@ -131,6 +327,12 @@ func (game *Game) resolveWinterOrders(orders []*Order) {
 
 			if len(region.incomingMoves) != 0 {
 				move := region.incomingMoves[0] // Max 1 incoming move in winter
+				game.trace.add(
+					ResolutionEventMoveSucceeded,
+					ResolutionCauseUncontested,
+					move,
+					region.Name,
+				)
 				region.Unit = ptr(move.unit())
 				game.board[move.Origin].removeUnit()
 				game.board.removeOrder(move)
@@ -139,21 +341,53 @@ func (game *Game) resolveWinterOrders(orders []*Order) {
 	}
 }
 
-func (game *Game) resolveNonWinterOrders(orders []*Order) {
+// Stops early if the given context is canceled, leaving the board partially resolved.
+func (game *Game) resolveNonWinterOrders(ctx context.Context, orders []*Order) {
+	startTime := time.Now()
+	defer func() {
+		resolutionDurationMetric.Observe(time.Since(startTime).Seconds())
+	}()
+
 	game.board.placeOrders(orders)
+	game.traceSupportsCutOnPlacement(orders)
 
 	game.resolveUncontestedRegions()
 	for !game.board.resolved() {
-		game.resolveContestedRegions()
+		if ctx.Err() != nil {
+			return
+		}
+
+		game.resolveContestedRegions(ctx)
 		game.resolveUncontestedRegions()
+		game.sendBoardDiff()
+	}
+
+	game.resolveRetreats(ctx)
+	if ctx.Err() != nil {
+		return
 	}
 
 	game.resolveSieges()
 }
 
-func (game *Game) resolveContestedRegions() {
+// Adds trace events for the given support orders that were not placed on the board, because their
+// origin regions were attacked.
+func (game *Game) traceSupportsCutOnPlacement(orders []*Order) {
+	for _, order := range orders {
+		if order.Type == OrderSupport && game.board[order.Origin].order != order {
+			game.trace.add(
+				ResolutionEventSupportCut,
+				ResolutionCauseOriginAttacked,
+				order,
+				order.Origin,
+			)
+		}
+	}
+}
+
+func (game *Game) resolveContestedRegions(ctx context.Context) {
 	for _, region := range game.board {
-		if waiting := game.resolveContestedRegion(region); !waiting {
+		if waiting := game.resolveContestedRegion(ctx, region); !waiting {
 			return
 		}
 	}
@@ -173,7 +407,8 @@ func (game *Game) resolveUncontestedRegions() {
 }
 
 func (game *Game) resolveUncontestedRegion(region *Region) (waiting bool) {
-	if mustWait := game.board.resolveUncontestedTransports(region); mustWait {
+	mustWait := game.board.resolveUncontestedTransports(region, game.UnitTypes, &game.trace)
+	if mustWait {
 		return true
 	}
 
@@ -196,7 +431,7 @@ func (game *Game) resolveUncontestedRegion(region *Region) (waiting bool) {
 	// Finds out if the region is part of a cycle (moves in a circle)
 	if !region.partOfCycle {
 		if cycle := game.board.findCycle(region.Name, region); cycle != nil {
-			cycle.prepareForResolving()
+			cycle.prepareForResolving(&game.trace)
 			return false
 		}
 	}
@@ -206,7 +441,7 @@ func (game *Game) resolveUncontestedRegion(region *Region) (waiting bool) {
 		if mustCross, _ := move.mustCrossDangerZone(region); mustCross {
 			return true
 		} else {
-			game.board.succeedMove(move)
+			game.board.succeedMove(move, game.UnitTypes, &game.trace, ResolutionCauseUncontested)
 			return false
 		}
 	}
@@ -214,26 +449,31 @@ func (game *Game) resolveUncontestedRegion(region *Region) (waiting bool) {
 	return true
 }
 
-func (game *Game) resolveContestedRegion(region *Region) (waiting bool) {
+func (game *Game) resolveContestedRegion(ctx context.Context, region *Region) (waiting bool) {
 	if region.resolved {
 		return true
 	}
-	if mustWait := game.resolveContestedTransports(region); mustWait {
+	if mustWait := game.resolveContestedTransports(ctx, region); mustWait {
 		return true
 	}
-	game.resolveDangerZoneCrossings(region)
+	game.resolveDangerZoneCrossings(ctx, region)
 
 	if borderBattle, secondRegion := game.board.findBorderBattle(region); borderBattle {
-		game.resolveBorderBattle(region, secondRegion)
+		game.resolveBorderBattle(ctx, region, secondRegion)
 		return false
 	}
 
 	// A single move to an empty region is either an autosuccess, or a singleplayer battle
 	if len(region.incomingMoves) == 1 && region.empty() {
 		if region.controlled() || region.Sea {
-			game.board.succeedMove(region.incomingMoves[0])
+			game.board.succeedMove(
+				region.incomingMoves[0],
+				game.UnitTypes,
+				&game.trace,
+				ResolutionCauseUncontested,
+			)
 		} else {
-			game.resolveSingleplayerBattle(region)
+			game.resolveSingleplayerBattle(ctx, region)
 		}
 		return false
 	}
@@ -245,13 +485,13 @@ func (game *Game) resolveContestedRegion(region *Region) (waiting bool) {
 
 	if region.resolvingKnightMoves {
 		// Checks if supports are cut by other knight moves
-		if mustWait := game.board.cutSupportsAttackedByKnightMoves(region); mustWait {
+		if mustWait := game.board.cutSupportsAttackedByKnightMoves(region, &game.trace); mustWait {
 			return true
 		}
 	}
 
 	// If the function has not returned yet, then it must be a multiplayer battle
-	game.resolveMultiplayerBattle(region)
+	game.resolveMultiplayerBattle(ctx, region)
 	return false
 }
 
@@ -260,21 +500,28 @@ func (game *Game) resolveSieges() {
 		if region.order != nil && region.order.Type == OrderBesiege {
 			region.SiegeCount++
 			if region.SiegeCount == 2 {
+				game.trace.add(
+					ResolutionEventCastleConquered,
+					ResolutionCauseSiege,
+					region.order,
+					region.Name,
+				)
 				region.ControllingFaction = region.Unit.Faction
 				region.SiegeCount = 0
+			} else {
+				game.trace.add(
+					ResolutionEventSiegeProgressed,
+					ResolutionCauseSiege,
+					region.order,
+					region.Name,
+				)
 			}
 		}
 	}
 }
 
 func (game *Game) checkWinner() (winner PlayerFaction) {
-	castleCount := make(map[PlayerFaction]int)
-
-	for _, region := range game.board {
-		if region.Castle && region.controlled() {
-			castleCount[region.ControllingFaction]++
-		}
-	}
+	castleCount := game.board.castleCounts()
 
 	tie := false
 	highestCount := 0
@@ -296,10 +543,9 @@ func (game *Game) checkWinner() (winner PlayerFaction) {
 	return highestCountFaction
 }
 
-func newPlayerInputContext() (ctx context.Context, cleanup context.CancelFunc) {
-	return context.WithTimeoutCause(
-		context.Background(),
-		1*time.Minute,
-		errors.New("timed out after 1 minute"),
-	)
+// Derives a context for awaiting player input in a battle from the given game context.
+func newPlayerInputContext(
+	ctx context.Context,
+) (inputCtx context.Context, cleanup context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, 1*time.Minute, errPlayerInputTimedOut)
 }
@@ -1,11 +1,18 @@
 package game
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
+	"maps"
 	"os"
 	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"hermannm.dev/devlog"
 	"hermannm.dev/devlog/log"
@@ -97,6 +104,34 @@ func TestNonWinterOrders(t *testing.T) {
 				"Lomone": empty,
 			},
 		},
+		{
+			name: "DeclaredSupport",
+			units: unitMap{
+				"Lomone": {Type: UnitFootman, Faction: green},
+				"Lusía":  {Type: UnitFootman, Faction: red},
+				"Limbol": {Type: UnitFootman, Faction: yellow},
+				"Leil":   {Type: UnitFootman, Faction: yellow},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Lomone", Destination: "Lusía"},
+				{
+					Type:             OrderSupport,
+					Origin:           "Limbol",
+					Destination:      "Lusía",
+					SupportedFaction: green,
+				},
+				{
+					Type:             OrderSupport,
+					Origin:           "Leil",
+					Destination:      "Lusía",
+					SupportedFaction: green,
+				},
+			},
+			expected: expectedUnits{
+				"Lusía":  movedFrom{"Lomone"},
+				"Lomone": empty,
+			},
+		},
 		{
 			name: "BorderBattle",
 			units: unitMap{
@@ -199,6 +234,58 @@ func TestNonWinterOrders(t *testing.T) {
 				"Mare Elle": stayed,
 			},
 		},
+		{
+			name: "AlliedTransport",
+			units: unitMap{
+				"Ovo":       {Type: UnitFootman, Faction: white},
+				"Mare Elle": {Type: UnitShip, Faction: green},
+			},
+			control: controlMap{
+				"Zona": white,
+			},
+			orders: []*Order{
+				{
+					Type:          OrderMove,
+					Origin:        "Ovo",
+					Destination:   "Zona",
+					TransportPath: []RegionName{"Mare Elle"},
+				},
+				{
+					Type:              OrderTransport,
+					Origin:            "Mare Elle",
+					TransportFactions: []PlayerFaction{white},
+				},
+			},
+			expected: expectedUnits{
+				"Zona":      movedFrom{"Ovo"},
+				"Ovo":       empty,
+				"Mare Elle": stayed,
+			},
+		},
+		{
+			name: "AlliedTransportWithoutConsent",
+			units: unitMap{
+				"Ovo":       {Type: UnitFootman, Faction: white},
+				"Mare Elle": {Type: UnitShip, Faction: green},
+			},
+			control: controlMap{
+				"Zona": white,
+			},
+			orders: []*Order{
+				{
+					Type:          OrderMove,
+					Origin:        "Ovo",
+					Destination:   "Zona",
+					TransportPath: []RegionName{"Mare Elle"},
+				},
+				{Type: OrderTransport, Origin: "Mare Elle"},
+			},
+			expected: expectedUnits{
+				"Zona":      empty,
+				"Ovo":       stayed,
+				"Mare Elle": stayed,
+			},
+		},
 		{
 			name: "TransportAttacked",
 			units: unitMap{
@@ -281,7 +368,7 @@ func TestNonWinterOrders(t *testing.T) {
 		t.Run(
 			test.name, func(t *testing.T) {
 				game, board := newMockGame(t, test.units, test.control, test.orders, SeasonSpring)
-				game.resolveNonWinterOrders(test.orders)
+				game.resolveNonWinterOrders(context.Background(), test.orders)
 				test.expected.check(t, board, test.units)
 			},
 		)
@@ -399,141 +486,1447 @@ func TestWinterOrders(t *testing.T) {
 	}
 }
 
-func BenchmarkBoardResolve(b *testing.B) {
-	for range b.N {
-		b.StopTimer()
-		game, orders := benchmarkSetup(b)
-		b.StartTimer()
-		game.resolveNonWinterOrders(orders)
+//nolint:exhaustruct
+func TestBuildPlan(t *testing.T) {
+	testCases := []struct {
+		name     string
+		units    unitMap
+		control  controlMap
+		faction  PlayerFaction
+		expected BuildPlan
+	}{
+		{
+			name: "Build",
+			units: unitMap{
+				"Calis": {Type: UnitFootman, Faction: yellow},
+			},
+			control: controlMap{
+				"Cymere": yellow,
+			},
+			faction: yellow,
+			expected: BuildPlan{
+				UnitCount:             1,
+				MaxUnitCount:          3,
+				AllowedBuilds:         2,
+				RequiredDisbands:      0,
+				HomeRegionsControlled: []RegionName{"Pesth", "Purth"},
+				BonusNations:          []string{"Caleren"},
+			},
+		},
+		{
+			name: "Disband",
+			units: unitMap{
+				"Monté":  {Type: UnitFootman, Faction: red},
+				"Brodo":  {Type: UnitFootman, Faction: red},
+				"Bassas": {Type: UnitFootman, Faction: red},
+				"Bom":    {Type: UnitKnight, Faction: yellow},
+			},
+			faction: red,
+			expected: BuildPlan{
+				UnitCount:             3,
+				MaxUnitCount:          2,
+				AllowedBuilds:         0,
+				RequiredDisbands:      1,
+				HomeRegionsControlled: []RegionName{"Monté", "Morone"},
+				BonusNations:          []string{},
+			},
+		},
 	}
-}
 
-//nolint:exhaustruct
-func benchmarkSetup(b *testing.B) (*Game, []*Order) {
-	b.Helper()
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			board, _ := newMockBoard(t, testCase.units, testCase.control, nil)
+
+			plan := board.buildPlan(testCase.faction)
+			if !reflect.DeepEqual(plan, testCase.expected) {
+				t.Errorf("want %+v, got %+v", testCase.expected, plan)
+			}
+		})
+	}
+}
 
+func TestStandings(t *testing.T) {
 	units := unitMap{
+		"Calis": {Type: UnitFootman, Faction: yellow},
 		"Emman": {Type: UnitFootman, Faction: white},
+	}
+	control := controlMap{
+		"Cymere": yellow,
+	}
+	game, _ := newMockGame(t, units, control, nil, SeasonSpring)
 
-		"Furie": {Type: UnitKnight, Faction: black},
+	standings := game.standings()
+	if len(standings) != len(game.PlayerFactions) {
+		t.Fatalf("want %d standings, got %d", len(game.PlayerFactions), len(standings))
+	}
 
-		"Gron":  {Type: UnitFootman, Faction: white},
-		"Gewel": {Type: UnitKnight, Faction: black},
+	expected := map[PlayerFaction]Standing{
+		yellow: {
+			Faction:           yellow,
+			CastleCount:       2, // Calis and Purth
+			RegionCount:       4,
+			UnitCount:         1,
+			NationsControlled: []string{"Caleren", "Pusth"},
+			CastlesToWin:      3,
+			Income:            0,
+			Treasury:          0,
+			Hidden:            false,
+		},
+		// White took Emman from black's home nation, so black no longer controls the whole nation
+		black: {
+			Faction:           black,
+			CastleCount:       1,
+			RegionCount:       1,
+			UnitCount:         0,
+			NationsControlled: []string{},
+			CastlesToWin:      4,
+			Income:            0,
+			Treasury:          0,
+			Hidden:            false,
+		},
+	}
+	for _, standing := range standings {
+		if expectedStanding, ok := expected[standing.Faction]; ok {
+			if !reflect.DeepEqual(standing, expectedStanding) {
+				t.Errorf("want %+v, got %+v", expectedStanding, standing)
+			}
+		}
+	}
+}
 
-		"Lomone": {Type: UnitFootman, Faction: green},
-		"Lusía":  {Type: UnitFootman, Faction: red},
-		"Brodo":  {Type: UnitFootman, Faction: red},
+//nolint:exhaustruct
+func TestEconomy(t *testing.T) {
+	testCases := []struct {
+		name       string
+		treasury   int
+		affordable bool
+	}{
+		{name: "Affordable", treasury: 5, affordable: true},
+		{name: "Unaffordable", treasury: 3, affordable: false},
+	}
 
-		"Tusser": {Type: UnitFootman, Faction: white},
-		"Tige":   {Type: UnitKnight, Faction: black},
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			units := unitMap{
+				"Calis": {Type: UnitFootman, Faction: yellow},
+			}
+			control := controlMap{
+				"Cymere": yellow,
+			}
+			orders := []*Order{ // Costs 2 + 2
+				{Type: OrderBuild, Origin: "Cymere", UnitType: UnitShip},
+				{Type: OrderBuild, Origin: "Pesth", UnitType: UnitKnight},
+			}
 
-		"Tond": {Type: UnitFootman, Faction: green},
+			game, board := newMockGame(t, units, control, orders, SeasonWinter)
+			game.rules.Economy = true
+			game.treasury[yellow] = testCase.treasury
+
+			errs := validateOrders(
+				orders,
+				yellow,
+				board,
+				game.BoardInfo,
+				game.treasuryOf(yellow),
+				SeasonWinter,
+			)
+			if !testCase.affordable {
+				if len(errs) != 1 || errorCode(errs[0]) != ErrorCodeInsufficientFunds {
+					t.Fatalf("want %s error, got %v", ErrorCodeInsufficientFunds, errs)
+				}
+				return
+			}
+			if errs != nil {
+				t.Fatalf("unexpected validation errors: %v", errs)
+			}
+
+			game.resolveWinterOrders(orders)
+			game.collectIncome()
+
+			// Yellow controls 4 regions, 2 of which are castles
+			expectedIncome := 6
+			expectedTreasury := testCase.treasury - 4 + expectedIncome
+			for _, standing := range game.standings() {
+				if standing.Faction != yellow {
+					continue
+				}
+				if standing.Income != expectedIncome || standing.Treasury != expectedTreasury {
+					t.Errorf(
+						"want income %d and treasury %d, got %d and %d",
+						expectedIncome,
+						expectedTreasury,
+						standing.Income,
+						standing.Treasury,
+					)
+				}
+			}
+		})
+	}
+}
 
+func TestBoardDiff(t *testing.T) {
+	units := unitMap{
 		"Ovo":       {Type: UnitFootman, Faction: green},
 		"Mare Elle": {Type: UnitShip, Faction: green},
-
-		"Winde":      {Type: UnitFootman, Faction: green},
-		"Mare Gond":  {Type: UnitShip, Faction: green},
-		"Mare Ovond": {Type: UnitShip, Faction: green},
-		"Mare Unna":  {Type: UnitShip, Faction: black},
-
-		"Leil":   {Type: UnitFootman, Faction: red},
-		"Limbol": {Type: UnitFootman, Faction: green},
-		"Worp":   {Type: UnitFootman, Faction: yellow},
 	}
-
+	control := controlMap{
+		"Zona": white,
+	}
 	orders := []*Order{
-		// Auto-success
-		{Type: OrderMove, Origin: "Emman", Destination: "Erren"},
-
-		// Singleplayer battle
-		{Type: OrderMove, Origin: "Furie", Destination: "Firril"},
-
-		// Multiplayer battle, no defender
-		{Type: OrderMove, Origin: "Gron", Destination: "Gnade"},
-		{Type: OrderMove, Origin: "Gewel", Destination: "Gnade"},
-
-		// Multiplayer battle with supported defender
-		{Type: OrderMove, Origin: "Lomone", Destination: "Lusía"},
-		{Type: OrderSupport, Origin: "Brodo", Destination: "Lusía"},
-
-		// Border battle
-		{Type: OrderMove, Origin: "Tusser", Destination: "Tige"},
-		{Type: OrderMove, Origin: "Tige", Destination: "Tusser"},
-
-		// Danger zone, dependent move
-		{Type: OrderMove, Origin: "Tond", Destination: "Tige"},
-
-		// Transport
 		{Type: OrderMove, Origin: "Ovo", Destination: "Zona"},
 		{Type: OrderTransport, Origin: "Mare Elle"},
+	}
+	game, board := newMockGame(t, units, control, orders, SeasonSpring)
 
-		// Transport attacked
-		{Type: OrderMove, Origin: "Winde", Destination: "Fond"},
-		{Type: OrderTransport, Origin: "Mare Gond"},
-		{Type: OrderTransport, Origin: "Mare Ovond"},
-		{Type: OrderMove, Origin: "Mare Unna", Destination: "Mare Ovond"},
+	previous := board.snapshot()
+	game.resolveNonWinterOrders(context.Background(), orders)
 
-		// Move cycle
-		{Type: OrderMove, Origin: "Leil", Destination: "Limbol"},
-		{Type: OrderMove, Origin: "Limbol", Destination: "Worp"},
-		{Type: OrderMove, Origin: "Worp", Destination: "Leil"},
+	expected := BoardDiff{
+		UnitChanges: []UnitChange{
+			{Region: "Ovo", Before: &Unit{Type: UnitFootman, Faction: green}, After: nil},
+			{Region: "Zona", Before: nil, After: &Unit{Type: UnitFootman, Faction: green}},
+		},
+		ControlChanges: []ControlChange{
+			{Region: "Zona", Before: white, After: green},
+		},
+		SiegeCountChanges: []SiegeCountChange{},
+	}
+	if diff := diffBoards(previous, board); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("want %+v, got %+v", expected, diff)
 	}
 
-	game, _ := newMockGame(b, units, nil, orders, SeasonSpring)
-	return game, orders
+	game.sendBoardDiff()
+	snapshot, _ := game.BoardSnapshot()
+	if diff := diffBoards(snapshot, board); !diff.Empty() {
+		t.Errorf("want snapshot to match board after sending diff, got diff %+v", diff)
+	}
 }
 
-func TestMain(m *testing.M) {
-	log.SetDefault(
-		devlog.NewHandler(os.Stdout, &devlog.Options{Level: slog.LevelDebug, ForceColors: true}),
-	)
-
-	board, boardInfo, err := ReadBoardFromConfigFile("casus-belli-5players")
-	if err != nil {
-		log.Error(nil, err, "Failed to read board config for tests")
-		os.Exit(1)
+//nolint:exhaustruct
+func TestResolutionTrace(t *testing.T) {
+	testCases := []struct {
+		name    string
+		units   unitMap
+		control controlMap
+		orders  []*Order
+		// The expected first events of the trace, and the index of each event's order in orders
+		expectedStart []ResolutionEvent
+		expectedOrder []int
+	}{
+		{
+			name: "Transport",
+			units: unitMap{
+				"Ovo":       {Type: UnitFootman, Faction: green},
+				"Mare Elle": {Type: UnitShip, Faction: green},
+			},
+			control: controlMap{
+				"Zona": white,
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Ovo", Destination: "Zona"},
+				{Type: OrderTransport, Origin: "Mare Elle"},
+			},
+			expectedStart: []ResolutionEvent{
+				{
+					Type:   ResolutionEventMoveSucceeded,
+					Cause:  ResolutionCauseUncontested,
+					Region: "Zona",
+				},
+			},
+			expectedOrder: []int{0},
+		},
+		{
+			name: "SupportCut",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+				"Gron":  {Type: UnitFootman, Faction: yellow},
+			},
+			orders: []*Order{
+				{Type: OrderSupport, Origin: "Emman", Destination: "Erren"},
+				{Type: OrderMove, Origin: "Gron", Destination: "Emman"},
+			},
+			expectedStart: []ResolutionEvent{
+				{
+					Type:   ResolutionEventSupportCut,
+					Cause:  ResolutionCauseOriginAttacked,
+					Region: "Emman",
+				},
+			},
+			expectedOrder: []int{0},
+		},
 	}
-	emptyBoard, baseBoardInfo = board, boardInfo
 
-	os.Exit(m.Run())
-}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			game, _ := newMockGame(
+				t,
+				testCase.units,
+				testCase.control,
+				testCase.orders,
+				SeasonSpring,
+			)
+			game.resolveNonWinterOrders(context.Background(), testCase.orders)
 
-var (
-	emptyBoard    Board
-	baseBoardInfo BoardInfo
-)
+			for i, orderIndex := range testCase.expectedOrder {
+				testCase.expectedStart[i].Order = testCase.orders[orderIndex]
+			}
 
-func diceRollerForTests() int {
-	return 3
+			if len(game.trace) < len(testCase.expectedStart) ||
+				!reflect.DeepEqual(
+					[]ResolutionEvent(game.trace[:len(testCase.expectedStart)]),
+					testCase.expectedStart,
+				) {
+				t.Errorf(
+					"want trace to start with %+v, got %+v",
+					testCase.expectedStart,
+					game.trace,
+				)
+			}
+		})
+	}
 }
 
-const (
-	yellow PlayerFaction = "Yellow"
-	red    PlayerFaction = "Red"
-	green  PlayerFaction = "Green"
-	white  PlayerFaction = "White"
-	black  PlayerFaction = "Black"
-)
-
-type unitMap map[RegionName]Unit
-type controlMap map[RegionName]PlayerFaction
-
-func newMockGame(
-	tb testing.TB,
-	units unitMap,
-	control controlMap,
-	orders []*Order,
-	season Season,
-) (*Game, Board) {
-	tb.Helper()
-
-	board := emptyBoard.copy()
-	ordersByFaction := make(map[PlayerFaction][]*Order)
-
-	for regionName, unit := range units {
-		region, ok := board[regionName]
-		if !ok {
+//nolint:exhaustruct
+func TestRetreatChoice(t *testing.T) {
+	testCases := []struct {
+		name    string
+		units   unitMap
+		control controlMap
+		orders  []*Order
+		// Maps the origins of retreating units to the regions their players choose
+		choices  map[RegionName]RegionName
+		expected expectedUnits
+	}{
+		{
+			name: "ChosenDestination",
+			units: unitMap{
+				"Gron": {Type: UnitFootman, Faction: white},
+			},
+			control: controlMap{
+				"Gnade": white,
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Gron", Destination: "Gewel"},
+			},
+			choices: map[RegionName]RegionName{"Gron": "Gnade"},
+			expected: expectedUnits{
+				"Gnade": movedFrom{"Gron"},
+				"Gron":  empty,
+				"Gewel": empty,
+			},
+		},
+		{
+			name: "InvalidDestination",
+			units: unitMap{
+				"Gron": {Type: UnitFootman, Faction: white},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Gron", Destination: "Gewel"},
+			},
+			choices: map[RegionName]RegionName{"Gron": "Gnade"},
+			expected: expectedUnits{
+				"Gron":  stayed,
+				"Gnade": empty,
+			},
+		},
+		{
+			name: "NoDestination",
+			units: unitMap{
+				"Furie": {Type: UnitKnight, Faction: black},
+			},
+			control: controlMap{
+				"Furie": green,
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Furie", Destination: "Firril"},
+			},
+			expected: expectedUnits{
+				"Furie":  empty,
+				"Firril": empty,
+			},
+		},
+		{
+			name: "Conflict",
+			units: unitMap{
+				"Limbol": {Type: UnitFootman, Faction: white},
+				"Ovo":    {Type: UnitFootman, Faction: white},
+			},
+			control: controlMap{
+				"Leil": white,
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Limbol", Destination: "Lomone"},
+				{Type: OrderMove, Origin: "Ovo", Destination: "Bassas"},
+			},
+			choices: map[RegionName]RegionName{"Limbol": "Leil", "Ovo": "Leil"},
+			expected: expectedUnits{
+				"Leil":   empty,
+				"Limbol": empty,
+				"Ovo":    empty,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			game, board := newMockGame(
+				t,
+				testCase.units,
+				testCase.control,
+				testCase.orders,
+				SeasonSpring,
+			)
+			game.rules.RetreatChoice = true
+			game.messenger = retreatMessenger{
+				MockMessenger: MockMessenger{},
+				choices:       testCase.choices,
+			}
+
+			game.resolveNonWinterOrders(context.Background(), testCase.orders)
+			testCase.expected.check(t, board, testCase.units)
+		})
+	}
+}
+
+//nolint:exhaustruct
+func TestHoldDefenseBonus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		bonus    int
+		expected expectedUnits
+	}{
+		{
+			name:  "NoBonus",
+			bonus: 0,
+			expected: expectedUnits{
+				"Leil":   movedFrom{"Limbol"},
+				"Limbol": empty,
+			},
+		},
+		{
+			name:  "Bonus",
+			bonus: 1,
+			expected: expectedUnits{
+				"Leil":   stayed,
+				"Limbol": stayed,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			units := unitMap{
+				"Limbol": {Type: UnitFootman, Faction: green},
+				"Lusía":  {Type: UnitFootman, Faction: green},
+				"Leil":   {Type: UnitFootman, Faction: red},
+			}
+			orders := []*Order{
+				{Type: OrderMove, Origin: "Limbol", Destination: "Leil"},
+				{Type: OrderSupport, Origin: "Lusía", Destination: "Leil"},
+				{Type: OrderHold, Origin: "Leil"},
+			}
+
+			game, board := newMockGame(t, units, nil, orders, SeasonSpring)
+			game.rules.HoldDefenseBonus = testCase.bonus
+
+			game.resolveNonWinterOrders(context.Background(), orders)
+			testCase.expected.check(t, board, units)
+		})
+	}
+}
+
+//nolint:exhaustruct
+func TestCustomUnitTypes(t *testing.T) {
+	const unitCavalry UnitType = 5
+
+	unitTypes := maps.Clone(baseBoardInfo.UnitTypes)
+	unitTypes[unitCavalry] = UnitTypeProperties{
+		Name:            "Cavalry",
+		MoveRange:       2,
+		AttackModifier:  2,
+		DefenseModifier: 0,
+	}
+
+	testCases := []struct {
+		name     string
+		units    unitMap
+		control  controlMap
+		orders   []*Order
+		expected expectedUnits
+	}{
+		{
+			name: "AttackModifier",
+			units: unitMap{
+				"Limbol": {Type: unitCavalry, Faction: green},
+				"Leil":   {Type: UnitFootman, Faction: red},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Limbol", Destination: "Leil"},
+			},
+			expected: expectedUnits{
+				"Leil":   movedFrom{"Limbol"},
+				"Limbol": empty,
+			},
+		},
+		{
+			name: "SecondDestination",
+			units: unitMap{
+				"Limbol": {Type: unitCavalry, Faction: green},
+			},
+			control: controlMap{
+				"Lusía":  green,
+				"Lomone": green,
+			},
+			orders: []*Order{
+				{
+					Type:              OrderMove,
+					Origin:            "Limbol",
+					Destination:       "Lusía",
+					SecondDestination: "Lomone",
+				},
+			},
+			expected: expectedUnits{
+				"Lomone": movedFrom{"Limbol"},
+				"Lusía":  empty,
+				"Limbol": empty,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			board, ordersByFaction := newMockBoard(
+				t,
+				testCase.units,
+				testCase.control,
+				testCase.orders,
+			)
+			boardInfo := baseBoardInfo
+			boardInfo.UnitTypes = unitTypes
+
+			for faction, orders := range ordersByFaction {
+				err := validateOrders(orders, faction, board, boardInfo, nil, SeasonSpring)
+				if err != nil {
+					t.Fatal(wrap.Error(err, "invalid orders in test setup"))
+				}
+			}
+
+			game := New(
+				board,
+				boardInfo,
+				Rules{},
+				MockMessenger{},
+				log.Default(),
+				diceRollerForTests,
+			)
+
+			game.resolveNonWinterOrders(context.Background(), testCase.orders)
+			testCase.expected.check(t, board, testCase.units)
+		})
+	}
+}
+
+//nolint:exhaustruct
+func TestVisibility(t *testing.T) {
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+		"Worp":  {Type: UnitFootman, Faction: green},
+		"Gron":  {Type: UnitFootman, Faction: yellow},
+	}
+	board, _ := newMockBoard(t, units, nil, nil)
+
+	visibility := board.VisibilityFor(white)
+
+	for _, region := range []RegionName{"Emman", "Gron", "Dordel", "Mare Elle"} {
+		if !visibility.CanSee(region) {
+			t.Errorf("want region '%s' to be visible", region)
+		}
+	}
+	for _, region := range []RegionName{"Worp", "Winde", "Leil"} {
+		if visibility.CanSee(region) {
+			t.Errorf("want region '%s' to be hidden", region)
+		}
+	}
+
+	filteredBoard := visibility.FilterBoard(board)
+	if filteredBoard["Worp"].Unit != nil || filteredBoard["Worp"].ControllingFaction != "" {
+		t.Errorf("want hidden region to have no unit or control, got %+v", *filteredBoard["Worp"])
+	}
+	if filteredBoard["Gron"].Unit == nil {
+		t.Error("want visible region to keep its unit")
+	}
+	if board["Worp"].Unit == nil {
+		t.Error("filtering the board should not modify the original")
+	}
+
+	hiddenMove := &Order{Type: OrderMove, Origin: "Worp", Destination: "Winde"}
+	visibleMove := &Order{Type: OrderMove, Origin: "Gron", Destination: "Gewel"}
+	filteredOrders := visibility.FilterOrders([]*Order{hiddenMove, visibleMove})
+	if !reflect.DeepEqual(filteredOrders, []*Order{visibleMove}) {
+		t.Errorf("want only visible move, got %v", filteredOrders)
+	}
+
+	hiddenBattle := Battle{Results: []Result{{Order: hiddenMove}}}
+	if visibility.CanSeeBattle(hiddenBattle) {
+		t.Error("want battle in hidden region to be hidden")
+	}
+
+	var fullVisibility *Visibility
+	if !fullVisibility.CanSee("Worp") || !fullVisibility.CanSeeBattle(hiddenBattle) {
+		t.Error("want nil visibility to see the whole board")
+	}
+}
+
+//nolint:exhaustruct
+func TestVisibilityChangeInBoardDiff(t *testing.T) {
+	previous, _ := newMockBoard(
+		t,
+		unitMap{
+			"Erren":  {Type: UnitFootman, Faction: white},
+			"Samoje": {Type: UnitFootman, Faction: yellow},
+			"Gron":   {Type: UnitFootman, Faction: green},
+		},
+		nil,
+		nil,
+	)
+
+	// White moves from Erren to Emman, and black takes Erren, so white can now see Gron (next to
+	// Emman) but no longer Samoje (only next to Erren)
+	current := previous.snapshot()
+	current["Emman"].Unit = &Unit{Type: UnitFootman, Faction: white}
+	current["Emman"].ControllingFaction = white
+	current["Erren"].Unit = &Unit{Type: UnitFootman, Faction: black}
+	current["Erren"].ControllingFaction = black
+
+	diff := diffBoards(previous, current)
+	visibleDiff := current.VisibilityFor(white).FilterBoardDiff(diff, current)
+
+	expectedUnitChanges := []UnitChange{
+		{Region: "Emman", Before: nil, After: &Unit{Type: UnitFootman, Faction: white}},
+		{
+			Region: "Erren",
+			Before: &Unit{Type: UnitFootman, Faction: white},
+			After:  &Unit{Type: UnitFootman, Faction: black},
+		},
+		{Region: "Gron", Before: nil, After: &Unit{Type: UnitFootman, Faction: green}}, // Revealed
+		{Region: "Samoje", Before: &Unit{Type: UnitFootman, Faction: yellow}, After: nil},
+	}
+	if !reflect.DeepEqual(visibleDiff.UnitChanges, expectedUnitChanges) {
+		t.Errorf("want unit changes %+v, got %+v", expectedUnitChanges, visibleDiff.UnitChanges)
+	}
+
+	revealedControl := ControlChange{Region: "Gron", Before: "", After: green}
+	if !slices.Contains(visibleDiff.ControlChanges, revealedControl) {
+		t.Errorf("want control of newly visible region, got %+v", visibleDiff.ControlChanges)
+	}
+
+	if diff := (*Visibility)(nil).FilterBoardDiff(diff, current); len(diff.UnitChanges) != 2 {
+		t.Errorf("want nil visibility to keep only actual changes, got %+v", diff.UnitChanges)
+	}
+}
+
+func TestFilterStandings(t *testing.T) {
+	standings := []Standing{
+		{
+			Faction:           white,
+			CastleCount:       3,
+			RegionCount:       8,
+			UnitCount:         4,
+			NationsControlled: []string{"Lusía"},
+			CastlesToWin:      2,
+			Income:            5,
+			Treasury:          7,
+			Hidden:            false,
+		},
+		{
+			Faction:           black,
+			CastleCount:       4,
+			RegionCount:       10,
+			UnitCount:         6,
+			NationsControlled: []string{"Bom"},
+			CastlesToWin:      1,
+			Income:            6,
+			Treasury:          2,
+			Hidden:            false,
+		},
+	}
+
+	board, _ := newMockBoard(t, nil, nil, nil)
+	filtered := board.VisibilityFor(white).FilterStandings(standings)
+
+	expected := []Standing{
+		standings[0],
+		{
+			Faction:           black,
+			CastleCount:       4,
+			RegionCount:       0,
+			UnitCount:         0,
+			NationsControlled: []string{},
+			CastlesToWin:      1,
+			Income:            0,
+			Treasury:          0,
+			Hidden:            true,
+		},
+	}
+	if !reflect.DeepEqual(filtered, expected) {
+		t.Errorf("want standings %+v, got %+v", expected, filtered)
+	}
+	if standings[1].Hidden {
+		t.Error("filtering standings should not modify the original")
+	}
+}
+
+//nolint:exhaustruct
+func TestInvalidOrders(t *testing.T) {
+	type expectedError struct {
+		code       ErrorCode
+		orderIndex int // -1 if the error should not be tied to an order
+		region     RegionName
+	}
+
+	testCases := []struct {
+		name     string
+		units    unitMap
+		control  controlMap
+		orders   []*Order
+		season   Season
+		expected []expectedError
+	}{
+		{
+			name: "UnknownDestination",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Atlantis"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeUnknownRegion, orderIndex: 0, region: "Atlantis"},
+			},
+		},
+		{
+			name: "LandUnitToSea",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+				"Furie": {Type: UnitFootman, Faction: white},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Erren"},
+				{Type: OrderMove, Origin: "Furie", Destination: "Mare Ovond"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeInvalidDestination, orderIndex: 1, region: "Mare Ovond"},
+			},
+		},
+		{
+			name: "DuplicateMoveDestination",
+			units: unitMap{
+				"Furie": {Type: UnitFootman, Faction: black},
+				"Gron":  {Type: UnitFootman, Faction: black},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Furie", Destination: "Firril"},
+				{Type: OrderMove, Origin: "Gron", Destination: "Firril"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeDuplicateMoveDestination, orderIndex: 1, region: "Firril"},
+			},
+		},
+		{
+			name: "TransportPathOverLand",
+			units: unitMap{
+				"Ovo":       {Type: UnitFootman, Faction: green},
+				"Mare Elle": {Type: UnitShip, Faction: green},
+			},
+			orders: []*Order{
+				{
+					Type:          OrderMove,
+					Origin:        "Ovo",
+					Destination:   "Zona",
+					TransportPath: []RegionName{"Mare Elle", "Zona"},
+				},
+				{Type: OrderTransport, Origin: "Mare Elle"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeInvalidTransportPath, orderIndex: 0, region: "Zona"},
+			},
+		},
+		{
+			name: "HoldWithDestination",
+			units: unitMap{
+				"Furie": {Type: UnitFootman, Faction: black},
+			},
+			orders: []*Order{
+				{Type: OrderHold, Origin: "Furie", Destination: "Firril"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeUnexpectedDestination, orderIndex: 0, region: "Furie"},
+			},
+		},
+		{
+			name: "UnknownSupportedFaction",
+			units: unitMap{
+				"Furie": {Type: UnitFootman, Faction: black},
+			},
+			orders: []*Order{
+				{
+					Type:             OrderSupport,
+					Origin:           "Furie",
+					Destination:      "Firril",
+					SupportedFaction: "Purple",
+				},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeInvalidSupportedFaction, orderIndex: 0, region: "Furie"},
+			},
+		},
+		{
+			name: "SupportInWinter",
+			units: unitMap{
+				"Furie": {Type: UnitFootman, Faction: black},
+			},
+			orders: []*Order{
+				{Type: OrderSupport, Origin: "Furie", Destination: "Firril"},
+			},
+			season: SeasonWinter,
+			expected: []expectedError{
+				{code: ErrorCodeInvalidOrderType, orderIndex: 0, region: "Furie"},
+			},
+		},
+		{
+			name: "MultipleErrors",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+				"Furie": {Type: UnitFootman, Faction: white},
+			},
+			orders: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Atlantis"},
+				{Type: OrderMove, Origin: "Furie", Destination: "Mare Ovond"},
+				{Type: OrderSupport, Origin: "Emman", Destination: "Erren"},
+			},
+			season: SeasonSpring,
+			expected: []expectedError{
+				{code: ErrorCodeUnknownRegion, orderIndex: 0, region: "Atlantis"},
+				{code: ErrorCodeInvalidDestination, orderIndex: 1, region: "Mare Ovond"},
+				{code: ErrorCodeDuplicateOrder, orderIndex: 2, region: "Emman"},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(
+			test.name, func(t *testing.T) {
+				board, ordersByFaction := newMockBoard(t, test.units, test.control, test.orders)
+				if len(ordersByFaction) != 1 {
+					t.Fatal("invalid test setup: orders must be from a single faction")
+				}
+
+				for faction, orders := range ordersByFaction {
+					errs := validateOrders(
+						orders,
+						faction,
+						board,
+						baseBoardInfo,
+						nil,
+						test.season,
+					)
+
+					actual := make([]expectedError, 0, len(errs))
+					for _, err := range errs {
+						var inputErr *InputError
+						if !errors.As(err, &inputErr) {
+							t.Fatalf("want InputError, got %v", err)
+						}
+						details := inputErr.Details
+
+						orderIndex := -1
+						if details.OrderIndex != nil {
+							orderIndex = *details.OrderIndex
+						}
+						actual = append(
+							actual,
+							expectedError{
+								code:       details.Code,
+								orderIndex: orderIndex,
+								region:     details.Region,
+							},
+						)
+					}
+
+					if !reflect.DeepEqual(actual, test.expected) {
+						t.Errorf("want %+v, got %+v (errors: %v)", test.expected, actual, errs)
+					}
+				}
+			},
+		)
+	}
+}
+
+//nolint:exhaustruct
+func TestOrderDraft(t *testing.T) {
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+		"Furie": {Type: UnitFootman, Faction: white},
+	}
+	board, _ := newMockBoard(t, units, nil, nil)
+
+	validMove := &Order{
+		Type: OrderMove, UnitType: UnitFootman, Origin: "Emman", Destination: "Erren",
+	}
+	invalidMove := &Order{
+		Type:        OrderMove,
+		UnitType:    UnitFootman,
+		Origin:      "Furie",
+		Destination: "Mare Ovond",
+	}
+	besiege := &Order{Type: OrderBesiege, UnitType: UnitFootman, Origin: "Furie"}
+	for _, order := range []*Order{validMove, invalidMove, besiege} {
+		order.Faction = white
+	}
+
+	steps := []struct {
+		input             OrderInput
+		expectedOrders    []*Order
+		expectedLastValid []*Order
+		expectedErrors    int
+	}{
+		{
+			input:             OrderInput{Type: OrderInputAddDraft, Order: validMove},
+			expectedOrders:    []*Order{validMove},
+			expectedLastValid: []*Order{validMove},
+		},
+		{
+			input:             OrderInput{Type: OrderInputAddDraft, Order: invalidMove},
+			expectedOrders:    []*Order{validMove, invalidMove},
+			expectedLastValid: []*Order{validMove},
+			expectedErrors:    1,
+		},
+		{
+			input:             OrderInput{Type: OrderInputEditDraft, Order: besiege, DraftIndex: 1},
+			expectedOrders:    []*Order{validMove, besiege},
+			expectedLastValid: []*Order{validMove},
+			expectedErrors:    1, // Furie has no castle
+		},
+		{
+			input:             OrderInput{Type: OrderInputRemoveDraft, DraftIndex: 0},
+			expectedOrders:    []*Order{besiege},
+			expectedLastValid: []*Order{validMove},
+			expectedErrors:    1,
+		},
+		{
+			input:             OrderInput{Type: OrderInputRemoveDraft, DraftIndex: 0},
+			expectedOrders:    []*Order{},
+			expectedLastValid: []*Order{},
+		},
+	}
+
+	var draft orderDraft
+	for i, step := range steps {
+		if err := draft.apply(step.input); err != nil {
+			t.Fatalf("step %d: unexpected error applying draft input: %v", i, err)
+		}
+		errs := draft.validate(white, board, baseBoardInfo, nil, SeasonSpring)
+
+		if len(errs) != step.expectedErrors {
+			t.Errorf("step %d: want %d errors, got %v", i, step.expectedErrors, errs)
+		}
+		if !reflect.DeepEqual(draft.orders, step.expectedOrders) {
+			t.Errorf("step %d: want draft %v, got %v", i, step.expectedOrders, draft.orders)
+		}
+		if !reflect.DeepEqual(draft.lastValid, step.expectedLastValid) {
+			t.Errorf(
+				"step %d: want last valid draft %v, got %v",
+				i,
+				step.expectedLastValid,
+				draft.lastValid,
+			)
+		}
+	}
+
+	err := draft.apply(OrderInput{Type: OrderInputRemoveDraft, DraftIndex: 0})
+	if errorCode(err) != ErrorCodeInvalidDraftIndex {
+		t.Errorf(
+			"want %s error when removing from empty draft, got %v",
+			ErrorCodeInvalidDraftIndex,
+			err,
+		)
+	}
+}
+
+//nolint:exhaustruct
+func TestLegalOrders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		units    unitMap
+		control  controlMap
+		season   Season
+		faction  PlayerFaction
+		region   RegionName
+		included []*Order
+		excluded []*Order
+	}{
+		{
+			name: "Move",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+			},
+			season:  SeasonSpring,
+			faction: white,
+			region:  "Emman",
+			included: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Erren"},
+				{Type: OrderSupport, Origin: "Emman", Destination: "Erren"},
+			},
+			excluded: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Mare Ovond"},
+				{Type: OrderTransport, Origin: "Emman"},
+			},
+		},
+		{
+			name: "Transport",
+			units: unitMap{
+				"Ovo":       {Type: UnitFootman, Faction: green},
+				"Mare Elle": {Type: UnitShip, Faction: green},
+			},
+			season:  SeasonSpring,
+			faction: green,
+			included: []*Order{
+				{Type: OrderMove, Origin: "Ovo", Destination: "Zona"},
+				{Type: OrderTransport, Origin: "Mare Elle"},
+			},
+			excluded: []*Order{
+				{Type: OrderSupport, Origin: "Ovo", Destination: "Zona"},
+				{Type: OrderBesiege, Origin: "Mare Elle"},
+			},
+		},
+		{
+			name: "OtherFaction",
+			units: unitMap{
+				"Emman": {Type: UnitFootman, Faction: white},
+			},
+			season:   SeasonSpring,
+			faction:  black,
+			included: nil,
+			excluded: []*Order{
+				{Type: OrderMove, Origin: "Emman", Destination: "Erren"},
+			},
+		},
+		{
+			name: "Build",
+			units: unitMap{
+				"Calis": {Type: UnitFootman, Faction: yellow},
+			},
+			control: controlMap{
+				"Cymere": yellow,
+				"Pesth":  yellow,
+			},
+			season:  SeasonWinter,
+			faction: yellow,
+			included: []*Order{
+				{Type: OrderBuild, Origin: "Cymere", UnitType: UnitShip},
+				{Type: OrderBuild, Origin: "Pesth", UnitType: UnitKnight},
+				{Type: OrderMove, Origin: "Calis", Destination: "Pesth"},
+			},
+			excluded: []*Order{
+				{Type: OrderBuild, Origin: "Calis", UnitType: UnitFootman},
+				{Type: OrderDisband, Origin: "Calis"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			expectedOrders := slices.Concat(testCase.included, testCase.excluded)
+			board, _ := newMockBoard(t, testCase.units, testCase.control, expectedOrders)
+
+			orders, err := LegalOrders(
+				board,
+				baseBoardInfo.UnitTypes,
+				nil,
+				testCase.season,
+				testCase.faction,
+				testCase.region,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			isLegal := func(order *Order) bool {
+				return slices.ContainsFunc(
+					orders,
+					func(legal *Order) bool { return reflect.DeepEqual(legal, order) },
+				)
+			}
+
+			for _, order := range testCase.included {
+				if !isLegal(order) {
+					t.Errorf("want legal order %+v, got %v", *order, orders)
+				}
+			}
+			for _, order := range testCase.excluded {
+				if isLegal(order) {
+					t.Errorf("want %+v not to be a legal order", *order)
+				}
+			}
+		})
+	}
+
+	_, err := LegalOrders(emptyBoard, baseBoardInfo.UnitTypes, nil, SeasonSpring, white, "Atlantis")
+	if errorCode(err) != ErrorCodeUnknownRegion {
+		t.Errorf("want %s error for unknown region, got %v", ErrorCodeUnknownRegion, err)
+	}
+}
+
+//nolint:exhaustruct
+func TestRetractAndResubmitOrders(t *testing.T) {
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+		"Furie": {Type: UnitFootman, Faction: black},
+	}
+	board, _ := newMockBoard(t, units, nil, nil)
+	boardInfo := baseBoardInfo
+	boardInfo.PlayerFactions = []PlayerFaction{white, black}
+
+	messenger := newScriptedOrderMessenger(boardInfo.PlayerFactions)
+	game := New(board, boardInfo, Rules{}, messenger, log.Default(), diceRollerForTests)
+	game.season = SeasonSpring
+
+	ordersChan := make(chan []*Order, 1)
+	go func() {
+		ordersChan <- game.gatherAndValidateOrders(context.Background())
+	}()
+
+	whiteMove := &Order{
+		Type: OrderMove, UnitType: UnitFootman, Origin: "Emman", Destination: "Erren",
+	}
+	blackMove := &Order{
+		Type:        OrderMove,
+		UnitType:    UnitFootman,
+		Origin:      "Furie",
+		Destination: "Firril",
+	}
+
+	messenger.send(t, white, OrderInput{Type: OrderInputSubmit, Orders: []*Order{whiteMove}})
+	messenger.send(t, white, OrderInput{Type: OrderInputRetract})
+	messenger.send(t, white, OrderInput{Type: OrderInputSubmit, Orders: []*Order{}})
+	messenger.send(t, black, OrderInput{Type: OrderInputSubmit, Orders: []*Order{blackMove}})
+
+	var orders []*Order
+	select {
+	case orders = <-ordersChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("order gathering did not stop after all players submitted")
+	}
+
+	if !reflect.DeepEqual(orders, []*Order{blackMove}) {
+		t.Errorf("want only black's orders after white retracted, got %v", orders)
+	}
+
+	messenger.lock.Lock()
+	defer messenger.lock.Unlock()
+	if messenger.confirmations != 3 || messenger.retractions != 1 {
+		t.Errorf(
+			"want 3 confirmations and 1 retraction, got %d and %d",
+			messenger.confirmations,
+			messenger.retractions,
+		)
+	}
+}
+
+//nolint:exhaustruct
+func TestCurrentDraftAfterReconnect(t *testing.T) {
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+		"Furie": {Type: UnitFootman, Faction: white},
+	}
+	board, _ := newMockBoard(t, units, nil, nil)
+	boardInfo := baseBoardInfo
+	boardInfo.PlayerFactions = []PlayerFaction{white}
+
+	messenger := newScriptedOrderMessenger(boardInfo.PlayerFactions)
+	game := New(board, boardInfo, Rules{}, messenger, log.Default(), diceRollerForTests)
+	game.season = SeasonSpring
+
+	ordersChan := make(chan []*Order, 1)
+	go func() {
+		ordersChan <- game.gatherAndValidateOrders(context.Background())
+	}()
+
+	validMove := &Order{
+		Type: OrderMove, UnitType: UnitFootman, Origin: "Emman", Destination: "Erren",
+	}
+	invalidMove := &Order{
+		Type:        OrderMove,
+		UnitType:    UnitFootman,
+		Origin:      "Furie",
+		Destination: "Mare Ovond",
+	}
+
+	messenger.send(t, white, OrderInput{Type: OrderInputAddDraft, Order: validMove})
+	messenger.send(t, white, OrderInput{Type: OrderInputAddDraft, Order: invalidMove})
+	// The game only waits for the next input once it has handled the previous one, so once this
+	// is received, the draft has been updated
+	messenger.send(t, white, OrderInput{Type: OrderInputLegalOrdersRequest, Region: "Emman"})
+
+	// A reconnecting player gets the draft from the game, since their client lost it
+	draft, errs, ok := game.CurrentDraft(white)
+	if !ok {
+		t.Fatal("want draft while gathering orders")
+	}
+	if !reflect.DeepEqual(draft, []*Order{validMove, invalidMove}) {
+		t.Errorf("want draft with both orders, got %v", draft)
+	}
+	if len(errs) != 1 {
+		t.Errorf("want 1 validation error for the invalid order, got %v", errs)
+	}
+
+	messenger.send(t, white, OrderInput{Type: OrderInputRemoveDraft, DraftIndex: 1})
+	messenger.send(t, white, OrderInput{Type: OrderInputSubmit, Orders: []*Order{validMove}})
+
+	select {
+	case <-ordersChan:
+	case <-time.After(5 * time.Second):
+		t.Fatal("order gathering did not stop after all players submitted")
+	}
+
+	if _, _, ok := game.CurrentDraft(white); ok {
+		t.Error("want no draft after order gathering has stopped")
+	}
+}
+
+//nolint:exhaustruct
+func TestSaveAfterStopKeepsTreasury(t *testing.T) {
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+		"Furie": {Type: UnitFootman, Faction: black},
+	}
+	board, _ := newMockBoard(t, units, nil, nil)
+	boardInfo := baseBoardInfo
+	boardInfo.PlayerFactions = []PlayerFaction{white, black}
+	expectedTreasury := board.income()
+
+	messenger := newScriptedOrderMessenger(boardInfo.PlayerFactions)
+	game := New(
+		board,
+		boardInfo,
+		Rules{Economy: true},
+		messenger,
+		log.Default(),
+		diceRollerForTests,
+	)
+	game.season = SeasonSpring
+
+	// Stops the game while it is waiting for orders, like on server shutdown, then saves it
+	runUntilStopped := func() map[PlayerFaction]int {
+		t.Helper()
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- game.Run(context.Background())
+		}()
+
+		messenger.send(t, white, OrderInput{Type: OrderInputLegalOrdersRequest, Region: "Emman"})
+		game.StopAfterCurrentStep(errors.New("server shutting down"))
+
+		select {
+		case err := <-errChan:
+			if !errors.Is(err, ErrGameAborted) {
+				t.Fatalf("want %v, got %v", ErrGameAborted, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("game did not stop")
+		}
+
+		var saved bytes.Buffer
+		if err := game.Save(&saved); err != nil {
+			t.Fatal(err)
+		}
+		var savedGame struct{ Treasury map[PlayerFaction]int }
+		if err := json.Unmarshal(saved.Bytes(), &savedGame); err != nil {
+			t.Fatal(err)
+		}
+		return savedGame.Treasury
+	}
+
+	// Only the first round's income, since the round was never resolved
+	if treasury := runUntilStopped(); !maps.Equal(treasury, expectedTreasury) {
+		t.Errorf("want saved treasury %v, got %v", expectedTreasury, treasury)
+	}
+
+	// Resuming the saved round should not collect its income again
+	game.stopLock.Lock()
+	game.stopCause = nil
+	game.stopLock.Unlock()
+	if treasury := runUntilStopped(); !maps.Equal(treasury, expectedTreasury) {
+		t.Errorf("want treasury %v after resuming, got %v", expectedTreasury, treasury)
+	}
+}
+
+func BenchmarkBoardResolve(b *testing.B) {
+	for range b.N {
+		b.StopTimer()
+		game, orders := benchmarkSetup(b)
+		b.StartTimer()
+		game.resolveNonWinterOrders(context.Background(), orders)
+	}
+}
+
+//nolint:exhaustruct
+func benchmarkSetup(b *testing.B) (*Game, []*Order) {
+	b.Helper()
+
+	units := unitMap{
+		"Emman": {Type: UnitFootman, Faction: white},
+
+		"Furie": {Type: UnitKnight, Faction: black},
+
+		"Gron":  {Type: UnitFootman, Faction: white},
+		"Gewel": {Type: UnitKnight, Faction: black},
+
+		"Lomone": {Type: UnitFootman, Faction: green},
+		"Lusía":  {Type: UnitFootman, Faction: red},
+		"Brodo":  {Type: UnitFootman, Faction: red},
+
+		"Tusser": {Type: UnitFootman, Faction: white},
+		"Tige":   {Type: UnitKnight, Faction: black},
+
+		"Tond": {Type: UnitFootman, Faction: green},
+
+		"Ovo":       {Type: UnitFootman, Faction: green},
+		"Mare Elle": {Type: UnitShip, Faction: green},
+
+		"Winde":      {Type: UnitFootman, Faction: green},
+		"Mare Gond":  {Type: UnitShip, Faction: green},
+		"Mare Ovond": {Type: UnitShip, Faction: green},
+		"Mare Unna":  {Type: UnitShip, Faction: black},
+
+		"Leil":   {Type: UnitFootman, Faction: red},
+		"Limbol": {Type: UnitFootman, Faction: green},
+		"Worp":   {Type: UnitFootman, Faction: yellow},
+	}
+
+	orders := []*Order{
+		// Auto-success
+		{Type: OrderMove, Origin: "Emman", Destination: "Erren"},
+
+		// Singleplayer battle
+		{Type: OrderMove, Origin: "Furie", Destination: "Firril"},
+
+		// Multiplayer battle, no defender
+		{Type: OrderMove, Origin: "Gron", Destination: "Gnade"},
+		{Type: OrderMove, Origin: "Gewel", Destination: "Gnade"},
+
+		// Multiplayer battle with supported defender
+		{Type: OrderMove, Origin: "Lomone", Destination: "Lusía"},
+		{Type: OrderSupport, Origin: "Brodo", Destination: "Lusía"},
+
+		// Border battle
+		{Type: OrderMove, Origin: "Tusser", Destination: "Tige"},
+		{Type: OrderMove, Origin: "Tige", Destination: "Tusser"},
+
+		// Danger zone, dependent move
+		{Type: OrderMove, Origin: "Tond", Destination: "Tige"},
+
+		// Transport
+		{Type: OrderMove, Origin: "Ovo", Destination: "Zona"},
+		{Type: OrderTransport, Origin: "Mare Elle"},
+
+		// Transport attacked
+		{Type: OrderMove, Origin: "Winde", Destination: "Fond"},
+		{Type: OrderTransport, Origin: "Mare Gond"},
+		{Type: OrderTransport, Origin: "Mare Ovond"},
+		{Type: OrderMove, Origin: "Mare Unna", Destination: "Mare Ovond"},
+
+		// Move cycle
+		{Type: OrderMove, Origin: "Leil", Destination: "Limbol"},
+		{Type: OrderMove, Origin: "Limbol", Destination: "Worp"},
+		{Type: OrderMove, Origin: "Worp", Destination: "Leil"},
+	}
+
+	game, _ := newMockGame(b, units, nil, orders, SeasonSpring)
+	return game, orders
+}
+
+func TestMain(m *testing.M) {
+	log.SetDefault(
+		devlog.NewHandler(os.Stdout, &devlog.Options{Level: slog.LevelDebug, ForceColors: true}),
+	)
+
+	board, boardInfo, err := ReadBoardFromConfigFile("casus-belli-5players")
+	if err != nil {
+		log.Error(nil, err, "Failed to read board config for tests")
+		os.Exit(1)
+	}
+	emptyBoard, baseBoardInfo = board, boardInfo
+
+	os.Exit(m.Run())
+}
+
+var (
+	emptyBoard    Board
+	baseBoardInfo BoardInfo
+)
+
+func diceRollerForTests() int {
+	return 3
+}
+
+const (
+	yellow PlayerFaction = "Yellow"
+	red    PlayerFaction = "Red"
+	green  PlayerFaction = "Green"
+	white  PlayerFaction = "White"
+	black  PlayerFaction = "Black"
+)
+
+type unitMap map[RegionName]Unit
+type controlMap map[RegionName]PlayerFaction
+
+func newMockGame(
+	tb testing.TB,
+	units unitMap,
+	control controlMap,
+	orders []*Order,
+	season Season,
+) (*Game, Board) {
+	tb.Helper()
+
+	board, ordersByFaction := newMockBoard(tb, units, control, orders)
+
+	for faction, orders := range ordersByFaction {
+		err := validateOrders(orders, faction, board, baseBoardInfo, nil, season)
+		if err != nil {
+			tb.Fatal(wrap.Error(err, "invalid orders in test setup"))
+		}
+	}
+
+	game := New(board, baseBoardInfo, Rules{}, MockMessenger{}, log.Default(), diceRollerForTests)
+	return game, board
+}
+
+// Places the given units and control on a copy of the empty board, and sets faction and unit type
+// on the given orders based on their origin regions. Returns the board along with the orders
+// grouped by faction, without validating them.
+func newMockBoard(
+	tb testing.TB,
+	units unitMap,
+	control controlMap,
+	orders []*Order,
+) (Board, map[PlayerFaction][]*Order) {
+	tb.Helper()
+
+	board := emptyBoard.copy()
+	ordersByFaction := make(map[PlayerFaction][]*Order)
+
+	for regionName, unit := range units {
+		region, ok := board[regionName]
+		if !ok {
 			tb.Fatalf("unit map contained region '%s' not found on board", regionName)
 		}
 
@@ -573,13 +1966,7 @@ func newMockGame(
 		ordersByFaction[order.Faction] = append(ordersByFaction[order.Faction], order)
 	}
 
-	for faction, orders := range ordersByFaction {
-		if err := validateOrders(orders, faction, board, season); err != nil {
-			tb.Fatal(wrap.Error(err, "invalid orders in test setup"))
-		}
-	}
-
-	return New(board, baseBoardInfo, MockMessenger{}, log.Default(), diceRollerForTests), board
+	return board, ordersByFaction
 }
 
 // Maps region names to either a Unit (which may be empty), a movedFrom struct, or stayed.
@@ -647,28 +2034,57 @@ func (MockMessenger) SendError(to PlayerFaction, err error) {}
 func (MockMessenger) SendGameStarted(board Board) {}
 
 //goland:noinspection GoUnusedParameter
-func (MockMessenger) SendOrderRequest(to PlayerFaction, season Season) (succeeded bool) {
+func (MockMessenger) SendOrderRequest(
+	to PlayerFaction,
+	season Season,
+	draft []*Order,
+	buildPlan *BuildPlan,
+) (succeeded bool) {
 	return true
 }
 
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendOrderDraft(to PlayerFaction, draft []*Order, errs OrderValidationErrors) {}
+
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendOrdersRetracted(factionThatRetractedOrders PlayerFaction) {}
+
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) SendOrdersReceived(orders map[PlayerFaction][]*Order) {}
 
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) SendOrdersConfirmation(factionThatSubmittedOrders PlayerFaction) {}
 
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendLegalOrders(to PlayerFaction, region RegionName, orders []*Order) {}
+
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) SendBattleAnnouncement(battle Battle) {}
 
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) SendBattleResults(battle Battle) {}
 
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendBoardDiff(diff BoardDiff) {}
+
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendRoundReport(season Season, events []ResolutionEvent) {}
+
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendStandings(season Season, standings []Standing) {}
+
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) SendWinner(winner PlayerFaction) {}
 
 //goland:noinspection GoUnusedParameter
-func (MockMessenger) AwaitOrders(ctx context.Context, from PlayerFaction) ([]*Order, error) {
-	return nil, nil
+func (MockMessenger) AwaitOrderInput(ctx context.Context, from PlayerFaction) (OrderInput, error) {
+	return OrderInput{
+		Type:       OrderInputSubmit,
+		Orders:     nil,
+		Order:      nil,
+		DraftIndex: 0,
+		Region:     "",
+	}, nil
 }
 
 //goland:noinspection GoUnusedParameter
@@ -680,9 +2096,100 @@ func (MockMessenger) AwaitSupport(
 	return "", nil
 }
 
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) SendRetreatRequest(to PlayerFaction, request RetreatRequest) {}
+
+//goland:noinspection GoUnusedParameter
+func (MockMessenger) AwaitRetreat(
+	ctx context.Context,
+	from PlayerFaction,
+	retreatingFrom RegionName,
+) (destination RegionName, err error) {
+	return "", nil
+}
+
 //goland:noinspection GoUnusedParameter
 func (MockMessenger) AwaitDiceRoll(ctx context.Context, from PlayerFaction) error {
 	return nil
 }
 
 func (MockMessenger) ClearMessages() {}
+
+// Messenger that feeds order input from tests to the game, and counts order confirmations.
+type scriptedOrderMessenger struct {
+	MockMessenger
+	inputs        map[PlayerFaction]chan OrderInput
+	confirmations int // Must hold lock to access safely.
+	retractions   int // Must hold lock to access safely.
+	lock          sync.Mutex
+}
+
+func newScriptedOrderMessenger(factions []PlayerFaction) *scriptedOrderMessenger {
+	inputs := make(map[PlayerFaction]chan OrderInput, len(factions))
+	for _, faction := range factions {
+		inputs[faction] = make(chan OrderInput)
+	}
+	return &scriptedOrderMessenger{
+		MockMessenger: MockMessenger{},
+		inputs:        inputs,
+		confirmations: 0,
+		retractions:   0,
+		lock:          sync.Mutex{},
+	}
+}
+
+// Sends the given input to the game, waiting until the game receives it.
+func (messenger *scriptedOrderMessenger) send(
+	t *testing.T,
+	from PlayerFaction,
+	input OrderInput,
+) {
+	t.Helper()
+
+	select {
+	case messenger.inputs[from] <- input:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("game did not receive order input from %s", from)
+	}
+}
+
+func (messenger *scriptedOrderMessenger) AwaitOrderInput(
+	ctx context.Context,
+	from PlayerFaction,
+) (OrderInput, error) {
+	select {
+	case input := <-messenger.inputs[from]:
+		return input, nil
+	case <-ctx.Done():
+		return OrderInput{}, context.Cause(ctx) //nolint:exhaustruct // Zero value on error
+	}
+}
+
+//goland:noinspection GoUnusedParameter
+func (messenger *scriptedOrderMessenger) SendOrdersConfirmation(faction PlayerFaction) {
+	messenger.lock.Lock()
+	defer messenger.lock.Unlock()
+	messenger.confirmations++
+}
+
+//goland:noinspection GoUnusedParameter
+func (messenger *scriptedOrderMessenger) SendOrdersRetracted(faction PlayerFaction) {
+	messenger.lock.Lock()
+	defer messenger.lock.Unlock()
+	messenger.retractions++
+}
+
+// Messenger that answers retreat requests with destinations chosen by tests.
+type retreatMessenger struct {
+	MockMessenger
+	choices map[RegionName]RegionName // Maps retreating units' origins to destinations.
+}
+
+//goland:noinspection GoUnusedParameter
+func (messenger retreatMessenger) AwaitRetreat(
+	ctx context.Context,
+	from PlayerFaction,
+	retreatingFrom RegionName,
+) (destination RegionName, err error) {
+	return messenger.choices[retreatingFrom], nil
+}
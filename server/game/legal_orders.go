@@ -0,0 +1,214 @@
+package game
+
+import (
+	"maps"
+	"slices"
+
+	"hermannm.dev/set"
+)
+
+// Returns every order that the given faction can legally give in the current season, so that
+// clients can highlight valid targets. If region is not blank, only orders for that region are
+// returned.
+//
+// Each order is legal on its own, but the orders may conflict with each other (e.g. two moves to
+// the same region), which is checked when the player submits their order set. Moves to regions
+// that are not adjacent are included if the unit could be transported there, assuming that all the
+// faction's ships at sea are given transport orders. Knight moves with second destinations are not
+// included, as they are just combinations of the returned moves. In games with the economy rule
+// (see [Rules]), the treasury is the faction's money, and builds that cost more are excluded.
+func LegalOrders(
+	board Board,
+	unitTypes UnitTypes,
+	treasury *int,
+	season Season,
+	faction PlayerFaction,
+	region RegionName,
+) ([]*Order, error) {
+	var regions []*Region
+	if region == "" {
+		for _, regionName := range slices.Sorted(maps.Keys(board)) {
+			regions = append(regions, board[regionName])
+		}
+	} else {
+		origin, ok := board[region]
+		if !ok {
+			return nil, unknownRegionError("requested", region)
+		}
+		regions = []*Region{origin}
+	}
+
+	var orders []*Order
+	if season == SeasonWinter {
+		plan := board.buildPlan(faction)
+		for _, origin := range regions {
+			orders = append(
+				orders,
+				legalWinterOrders(origin, faction, board, unitTypes, treasury, plan)...,
+			)
+		}
+	} else {
+		transportBoard := boardWithAllTransports(board, unitTypes, faction)
+		for _, origin := range regions {
+			orders = append(
+				orders,
+				legalNonWinterOrders(origin, faction, board, unitTypes, transportBoard)...,
+			)
+		}
+	}
+
+	return orders, nil
+}
+
+func legalNonWinterOrders(
+	origin *Region,
+	faction PlayerFaction,
+	board Board,
+	unitTypes UnitTypes,
+	transportBoard Board,
+) []*Order {
+	if origin.empty() || origin.Unit.Faction != faction {
+		return nil
+	}
+
+	var candidates []*Order
+	for _, destinationName := range slices.Sorted(maps.Keys(board)) {
+		if destinationName == origin.Name {
+			continue
+		}
+
+		if !origin.adjacentTo(destinationName) {
+			move := newLegalOrderCandidate(OrderMove, origin, destinationName)
+			if validateReachableMoveDestination(move, transportBoard, unitTypes) == nil {
+				candidates = append(candidates, move)
+			}
+			continue
+		}
+
+		// Regions may be adjacent both directly and through danger zones, so we add a move for
+		// each way of getting there
+		var dangerZones set.ArraySet[DangerZone]
+		for _, neighbor := range origin.Neighbors {
+			if neighbor.Name == destinationName && !dangerZones.Contains(neighbor.DangerZone) {
+				dangerZones.Add(neighbor.DangerZone)
+				move := newLegalOrderCandidate(OrderMove, origin, destinationName)
+				move.ViaDangerZone = neighbor.DangerZone
+				candidates = append(candidates, move)
+			}
+		}
+
+		candidates = append(
+			candidates,
+			newLegalOrderCandidate(OrderSupport, origin, destinationName),
+		)
+	}
+	candidates = append(
+		candidates,
+		newLegalOrderCandidate(OrderBesiege, origin, ""),
+		newLegalOrderCandidate(OrderTransport, origin, ""),
+		newLegalOrderCandidate(OrderHold, origin, ""),
+	)
+
+	// Candidates never name a faction to support, so we don't need to pass the game's factions
+	var legalOrders []*Order
+	for _, order := range candidates {
+		if validateNonWinterOrder(order, origin, board, unitTypes, nil) == nil {
+			legalOrders = append(legalOrders, order)
+		}
+	}
+	return legalOrders
+}
+
+func legalWinterOrders(
+	origin *Region,
+	faction PlayerFaction,
+	board Board,
+	unitTypes UnitTypes,
+	treasury *int,
+	plan BuildPlan,
+) []*Order {
+	var candidates []*Order
+
+	if origin.empty() {
+		if plan.AllowedBuilds > 0 && origin.ControllingFaction == faction && !origin.Sea {
+			for _, unitType := range slices.Sorted(maps.Keys(unitTypes)) {
+				if treasury != nil && unitTypes[unitType].BuildCost > *treasury {
+					continue
+				}
+
+				build := newLegalOrderCandidate(OrderBuild, origin, "")
+				build.UnitType = unitType
+				candidates = append(candidates, build)
+			}
+		}
+	} else if origin.Unit.Faction == faction {
+		if plan.RequiredDisbands > 0 {
+			candidates = append(candidates, newLegalOrderCandidate(OrderDisband, origin, ""))
+		}
+
+		for _, destinationName := range slices.Sorted(maps.Keys(board)) {
+			if destinationName != origin.Name {
+				candidates = append(
+					candidates,
+					newLegalOrderCandidate(OrderMove, origin, destinationName),
+				)
+			}
+		}
+	}
+
+	// Checks orders on their own, without taking other orders into account
+	var noDisbands, noOutgoingMoves set.ArraySet[RegionName]
+
+	var legalOrders []*Order
+	for _, order := range candidates {
+		err := validateWinterOrder(order, origin, board, unitTypes, noDisbands, noOutgoingMoves)
+		if err == nil {
+			legalOrders = append(legalOrders, order)
+		}
+	}
+	return legalOrders
+}
+
+// Returns an order of the given type for the unit in the given region, or for building a unit in
+// it if empty. Build orders must have their unit type set by the caller.
+func newLegalOrderCandidate(
+	orderType OrderType,
+	origin *Region,
+	destination RegionName,
+) *Order {
+	order := Order{
+		Type:              orderType,
+		UnitType:          0,
+		Retreat:           false,
+		Faction:           origin.ControllingFaction,
+		Origin:            origin.Name,
+		Destination:       destination,
+		SecondDestination: "",
+		ViaDangerZone:     "",
+		TransportPath:     nil,
+		TransportFactions: nil,
+		SupportedFaction:  "",
+	}
+	if !origin.empty() {
+		order.UnitType = origin.Unit.Type
+		order.Faction = origin.Unit.Faction
+	}
+	return &order
+}
+
+// Returns a copy of the board where all the given faction's ships at sea have transport orders,
+// for finding every region that the faction's land units could be transported to.
+func boardWithAllTransports(board Board, unitTypes UnitTypes, faction PlayerFaction) Board {
+	var transports []*Order
+	for _, region := range board {
+		if region.Sea && !region.empty() && region.Unit.Faction == faction &&
+			unitTypes[region.Unit.Type].Naval {
+			transports = append(transports, newLegalOrderCandidate(OrderTransport, region, ""))
+		}
+	}
+
+	// Copy the board, so orders do not persist
+	boardCopy := board.copy()
+	boardCopy.placeOrders(transports)
+	return boardCopy
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"errors"
+
+	"hermannm.dev/casus-belli/server/metrics"
+)
+
+var (
+	roundsResolvedMetric = metrics.Default.NewCounter(
+		"casus_belli_rounds_resolved_total",
+		"Number of rounds resolved across all games.",
+	)
+	battlesMetric = metrics.Default.NewCounter(
+		"casus_belli_battles_total",
+		"Number of battles resolved, by kind of battle.",
+		"kind",
+	)
+	orderValidationFailuresMetric = metrics.Default.NewCounter(
+		"casus_belli_order_validation_failures_total",
+		"Number of errors found when validating submitted orders, by error code.",
+		"code",
+	)
+	timeoutsMetric = metrics.Default.NewCounter(
+		"casus_belli_timeouts_total",
+		"Number of times the server timed out waiting for player input, by kind of input.",
+		"kind",
+	)
+	resolutionDurationMetric = metrics.Default.NewHistogram(
+		"casus_belli_resolution_duration_seconds",
+		"Time taken to resolve orders in non-winter rounds, including waiting for player input.",
+		[]float64{0.01, 0.1, 1, 5, 15, 30, 60, 120, 300, 600},
+	)
+)
+
+// Values for the "kind" label of battlesMetric.
+const (
+	battleKindSingleplayer = "singleplayer"
+	battleKindMultiplayer  = "multiplayer"
+	battleKindBorder       = "border"
+	battleKindDangerZone   = "danger_zone"
+)
+
+// Context causes for player input timeouts. These are shared between games, so they are plain
+// errors: an InputError is made for each reported timeout in handleTimeout, since the methods on
+// InputError modify it in place.
+var (
+	errOrdersTimedOut      = errors.New("timed out after 15 minutes")
+	errPlayerInputTimedOut = errors.New("timed out after 1 minute")
+)
+
+// If the given error was caused by a player input timeout, increments timeoutsMetric and returns
+// the error wrapped in an InputError with [ErrorCodeTimedOut]. Otherwise, returns the error as is.
+func handleTimeout(err error) error {
+	switch {
+	case errors.Is(err, errOrdersTimedOut):
+		timeoutsMetric.Inc("orders")
+	case errors.Is(err, errPlayerInputTimedOut):
+		timeoutsMetric.Inc("player_input")
+	default:
+		return err
+	}
+
+	return newInputError(ErrorCodeTimedOut, err)
+}
@@ -49,6 +49,9 @@ const (
 
 	// Bonus from supporting player in a battle.
 	ModifierSupport
+
+	// Bonus for defending with a hold order, if enabled in the game's rules (see [Rules]).
+	ModifierHold
 )
 
 var modifierNames = enumnames.NewMap(
@@ -60,6 +63,7 @@ var modifierNames = enumnames.NewMap(
 		ModifierWater:    "Water",
 		ModifierSurprise: "Surprise",
 		ModifierSupport:  "Support",
+		ModifierHold:     "Hold",
 	},
 )
 
@@ -67,15 +71,26 @@ func (modifierType ModifierType) String() string {
 	return modifierNames.GetNameOrFallback(modifierType, "INVALID")
 }
 
-func (game *Game) newDefenderResult(unit Unit) Result {
+// Returns all valid modifier types (used for generating protocol schemas).
+func (ModifierType) Values() []ModifierType {
+	return modifierNames.Keys()
+}
+
+func (game *Game) newDefenderResult(unit Unit, holding bool) Result {
 	var modifiers []Modifier
 	total := 0
 
-	if unitModifier, hasModifier := unit.Type.battleModifier(false); hasModifier {
+	if unitModifier, hasModifier := game.UnitTypes.defenseModifier(unit.Type); hasModifier {
 		modifiers = append(modifiers, unitModifier)
 		total += unitModifier.Value
 	}
 
+	if holding && game.rules.HoldDefenseBonus != 0 {
+		holdModifier := newModifier(ModifierHold, game.rules.HoldDefenseBonus)
+		modifiers = append(modifiers, holdModifier)
+		total += holdModifier.Value
+	}
+
 	return Result{
 		DefenderFaction: unit.Faction,
 		Parts:           modifiers,
@@ -120,7 +135,8 @@ func (game *Game) newAttackerResult(
 		}
 	}
 
-	if unitModifier, hasModifier := move.UnitType.battleModifier(region.Castle); hasModifier {
+	unitModifier, hasModifier := game.UnitTypes.attackModifier(move.UnitType, region.Castle)
+	if hasModifier {
 		modifiers = append(modifiers, unitModifier)
 	}
 
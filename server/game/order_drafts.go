@@ -0,0 +1,177 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Input from a player while the game is waiting for their orders: either a change to their order
+// draft, or a submission of their full order set.
+type OrderInput struct {
+	Type OrderInputType
+
+	// For OrderInputSubmit: the submitted orders. All elements must be non-nil.
+	Orders []*Order
+
+	// For OrderInputAddDraft and OrderInputEditDraft: the order to add, or to replace the existing
+	// order with. Must be non-nil.
+	Order *Order
+
+	// For OrderInputEditDraft and OrderInputRemoveDraft: index of the order in the draft.
+	DraftIndex int
+
+	// For OrderInputLegalOrdersRequest: the region to get legal orders for, or blank for all the
+	// player's regions.
+	Region RegionName
+}
+
+type OrderInputType uint8
+
+const (
+	// The player submitted their orders for the round.
+	OrderInputSubmit OrderInputType = iota + 1
+
+	// The player added an order to the end of their draft.
+	OrderInputAddDraft
+
+	// The player replaced an order in their draft.
+	OrderInputEditDraft
+
+	// The player removed an order from their draft.
+	OrderInputRemoveDraft
+
+	// The player retracted their submitted orders, in order to change them. Only allowed until all
+	// players have submitted.
+	OrderInputRetract
+
+	// The player asked for the legal orders for their units (see [LegalOrders]). Does not change
+	// the draft.
+	OrderInputLegalOrdersRequest
+)
+
+// A player's in-progress orders for the current round, which they can build up one order at a time
+// before submitting. The server keeps the draft, so that it is not lost if the player's order set
+// is rejected, and so that the player's orders are not lost if they time out.
+type orderDraft struct {
+	orders []*Order
+
+	// Copy of the orders from the last time the draft passed validation. Used in place of the
+	// player's orders if they do not submit in time.
+	lastValid []*Order
+
+	// Errors from the last validation of the draft's current orders.
+	errs OrderValidationErrors
+}
+
+// Returns the given player's order draft and its validation errors, if the game is currently
+// gathering orders from them. Safe to call while the game is running, e.g. to give the draft back
+// to a player that reconnects. The returned orders must not be modified.
+func (game *Game) CurrentDraft(
+	faction PlayerFaction,
+) (draft []*Order, errs OrderValidationErrors, ok bool) {
+	game.draftsLock.RLock()
+	defer game.draftsLock.RUnlock()
+
+	current, ok := game.drafts[faction]
+	if !ok {
+		return nil, nil, false
+	}
+	return slices.Clone(current.orders), slices.Clone(current.errs), true
+}
+
+// Creates an empty order draft for the given player, which is readable through
+// [Game.CurrentDraft] until removed with [Game.removeDraft].
+func (game *Game) newDraft(faction PlayerFaction) *orderDraft {
+	game.draftsLock.Lock()
+	defer game.draftsLock.Unlock()
+
+	draft := &orderDraft{orders: nil, lastValid: nil, errs: nil}
+	game.drafts[faction] = draft
+	return draft
+}
+
+func (game *Game) removeDraft(faction PlayerFaction) {
+	game.draftsLock.Lock()
+	defer game.draftsLock.Unlock()
+
+	delete(game.drafts, faction)
+}
+
+// Applies the given input to the player's draft (see [orderDraft.apply]), holding the lock so that
+// the draft can be read concurrently.
+func (game *Game) applyToDraft(draft *orderDraft, input OrderInput) error {
+	game.draftsLock.Lock()
+	defer game.draftsLock.Unlock()
+
+	return draft.apply(input)
+}
+
+// Validates the player's draft against the current board (see [orderDraft.validate]), holding the
+// lock so that the draft can be read concurrently.
+func (game *Game) validateDraft(faction PlayerFaction, draft *orderDraft) OrderValidationErrors {
+	game.draftsLock.Lock()
+	defer game.draftsLock.Unlock()
+
+	return draft.validate(
+		faction,
+		game.board,
+		game.BoardInfo,
+		game.treasuryOf(faction),
+		game.season,
+	)
+}
+
+// Applies the given input to the draft. A submission replaces the draft with the submitted
+// orders, while a retraction leaves the draft as is. Assumes that the orders' faction has already
+// been set.
+func (draft *orderDraft) apply(input OrderInput) error {
+	switch input.Type {
+	case OrderInputAddDraft:
+		draft.orders = append(draft.orders, input.Order)
+	case OrderInputEditDraft:
+		if err := draft.checkIndex(input.DraftIndex); err != nil {
+			return err
+		}
+		draft.orders[input.DraftIndex] = input.Order
+	case OrderInputRemoveDraft:
+		if err := draft.checkIndex(input.DraftIndex); err != nil {
+			return err
+		}
+		draft.orders = slices.Delete(draft.orders, input.DraftIndex, input.DraftIndex+1)
+	case OrderInputSubmit:
+		draft.orders = slices.Clone(input.Orders)
+	case OrderInputRetract:
+		// The draft already holds the submitted orders
+	default:
+		return fmt.Errorf("invalid order input type '%d'", input.Type)
+	}
+
+	return nil
+}
+
+func (draft *orderDraft) checkIndex(index int) error {
+	if index < 0 || index >= len(draft.orders) {
+		return newInputError(
+			ErrorCodeInvalidDraftIndex,
+			fmt.Errorf("no order at index %d in draft of %d orders", index, len(draft.orders)),
+		)
+	}
+	return nil
+}
+
+// Validates the draft's current orders, and stores them as the last valid draft if they pass.
+// Also stores the errors, for players that ask for the draft later.
+func (draft *orderDraft) validate(
+	faction PlayerFaction,
+	board Board,
+	boardInfo BoardInfo,
+	treasury *int,
+	season Season,
+) OrderValidationErrors {
+	errs := validateOrders(draft.orders, faction, board, boardInfo, treasury, season)
+	if errs == nil {
+		draft.lastValid = slices.Clone(draft.orders)
+	}
+	draft.errs = errs
+	return errs
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"hermannm.dev/enumnames"
@@ -38,6 +41,22 @@ type Order struct {
 
 	// For move orders: name of DangerZone the order tries to pass through, if any.
 	ViaDangerZone DangerZone
+
+	// For move orders to non-adjacent regions: optional list of sea regions with transporting ships
+	// to move through, in order from origin to destination. If blank, the server picks the safest
+	// transport path through the player's own ships. Must be given to move with other factions'
+	// ships, since their orders are not known when the player's orders are validated.
+	TransportPath []RegionName `json:",omitempty"`
+
+	// For transport orders: other factions whose units the ship agrees to transport, in addition to
+	// units of its own faction.
+	TransportFactions []PlayerFaction `json:",omitempty"`
+
+	// For support orders: optional faction to support in a battle in the destination region,
+	// decided when submitting orders, so that the player is not asked during the battle. If the
+	// faction is not in the battle, the support is not given. Setting this to the player's own
+	// faction means only supporting battles that the player fights in.
+	SupportedFaction PlayerFaction `json:",omitempty"`
 }
 
 type OrderType uint8
@@ -62,6 +81,10 @@ const (
 	// For region with a player's own unit, in winter: an order to disband the unit, when their
 	// current number of units exceeds their max number of units.
 	OrderDisband
+
+	// For any unit outside of winter: an order to stay in its region and defend it, which gives a
+	// defensive bonus in battles if enabled in the game's rules (see [Rules]).
+	OrderHold
 )
 
 var orderNames = enumnames.NewMap(
@@ -72,6 +95,7 @@ var orderNames = enumnames.NewMap(
 		OrderBesiege:   "Besiege",
 		OrderBuild:     "Build",
 		OrderDisband:   "Disband",
+		OrderHold:      "Hold",
 	},
 )
 
@@ -79,13 +103,19 @@ func (orderType OrderType) String() string {
 	return orderNames.GetNameOrFallback(orderType, "INVALID")
 }
 
+// Returns all valid order types (used for generating protocol schemas).
+func (OrderType) Values() []OrderType {
+	return orderNames.Keys()
+}
+
 func (order *Order) unit() Unit {
 	return Unit{Type: order.UnitType, Faction: order.Faction}
 }
 
-// Checks if the order is a move by a knight unit with a second destination.
+// Checks if the order is a move with a second destination, which is only allowed for units with a
+// move range of 2 (such as knights).
 func (order *Order) hasKnightMove() bool {
-	return order.Type == OrderMove && order.UnitType == UnitKnight && order.SecondDestination != ""
+	return order.Type == OrderMove && order.SecondDestination != ""
 }
 
 // Returns a copy of the order with the original destination set as the origin, and the destination
@@ -95,6 +125,7 @@ func (order *Order) knightMove() *Order {
 	knightMove.Origin = order.Destination
 	knightMove.Destination = order.SecondDestination
 	knightMove.SecondDestination = ""
+	knightMove.TransportPath = nil
 	return &knightMove
 }
 
@@ -105,9 +136,17 @@ func (order *Order) retreat() *Order {
 	retreat.Retreat = true
 	retreat.Origin, retreat.Destination = retreat.Destination, retreat.Origin
 	retreat.SecondDestination = ""
+	retreat.TransportPath = nil
 	return &retreat
 }
 
+// Checks if the order is a transport that carries units of the given faction: either the ship's own
+// faction, or one of the factions that the ship agrees to transport.
+func (order *Order) transports(faction PlayerFaction) bool {
+	return order.Type == OrderTransport &&
+		(order.Faction == faction || slices.Contains(order.TransportFactions, faction))
+}
+
 func (order *Order) mustCrossDangerZone(
 	destination *Region,
 ) (mustCross bool, dangerZone DangerZone) {
@@ -129,19 +168,19 @@ func countOrdersFromFaction(orders []*Order, faction PlayerFaction) int {
 	return count
 }
 
-func (game *Game) gatherAndValidateOrders() []*Order {
-	ctx, cleanup := context.WithTimeoutCause(
-		context.Background(),
-		15*time.Minute,
-		errors.New("timed out after 15 minutes"),
-	)
+func (game *Game) gatherAndValidateOrders(ctx context.Context) []*Order {
+	gatherCtx, cleanup := context.WithTimeoutCause(ctx, 15*time.Minute, errOrdersTimedOut)
 	defer cleanup()
 
+	gatherCtx, allDone := context.WithCancelCause(gatherCtx)
+	defer allDone(nil)
+	submissions := newOrderSubmissions(game.PlayerFactions, allDone)
+
 	orderChans := make(map[PlayerFaction]chan []*Order, len(game.PlayerFactions))
 	for _, faction := range game.PlayerFactions {
 		orderChan := make(chan []*Order, 1)
 		orderChans[faction] = orderChan
-		go game.gatherAndValidateOrderSet(ctx, faction, orderChan)
+		go game.gatherAndValidateOrderSet(gatherCtx, faction, submissions, orderChan)
 	}
 
 	var allOrders []*Order
@@ -152,61 +191,279 @@ func (game *Game) gatherAndValidateOrders() []*Order {
 		factionOrders[faction] = orders
 	}
 
-	game.messenger.SendOrdersReceived(factionOrders)
+	// If the game was stopped while gathering orders, the orders will be discarded (timeouts are
+	// not included here, since then we continue with the orders we got)
+	if ctx.Err() == nil {
+		game.messenger.SendOrdersReceived(factionOrders)
+	}
 	return allOrders
 }
 
+// Cause of the order gathering context when all players are done giving orders.
+var errAllOrdersReceived = errors.New("all orders received")
+
+// Tracks which player factions are done giving orders, so that order gathering can stop once all
+// of them are. Players may retract their orders until then.
+type orderSubmissions struct {
+	done     map[PlayerFaction]bool // Must hold lock to access safely.
+	allDone  context.CancelCauseFunc
+	finished bool // Must hold lock to access safely.
+	lock     sync.Mutex
+}
+
+func newOrderSubmissions(
+	factions []PlayerFaction,
+	allDone context.CancelCauseFunc,
+) *orderSubmissions {
+	done := make(map[PlayerFaction]bool, len(factions))
+	for _, faction := range factions {
+		done[faction] = false
+	}
+	return &orderSubmissions{done: done, allDone: allDone, finished: false, lock: sync.Mutex{}}
+}
+
+// Marks the given faction as done giving orders. If all factions are now done, order gathering is
+// stopped.
+func (submissions *orderSubmissions) markDone(faction PlayerFaction) {
+	submissions.lock.Lock()
+	defer submissions.lock.Unlock()
+
+	submissions.done[faction] = true
+	for _, done := range submissions.done {
+		if !done {
+			return
+		}
+	}
+
+	submissions.finished = true
+	submissions.allDone(errAllOrdersReceived)
+}
+
+// Marks the given faction as no longer done giving orders, e.g. because they retracted their
+// orders. Returns false if all factions were already done, in which case it is too late.
+func (submissions *orderSubmissions) markNotDone(faction PlayerFaction) (ok bool) {
+	submissions.lock.Lock()
+	defer submissions.lock.Unlock()
+
+	if submissions.finished {
+		return false
+	}
+
+	submissions.done[faction] = false
+	return true
+}
+
 // Waits for the given player to submit orders, then validates them.
-// If valid, sends the order set to the given output channel.
 // If invalid, informs the client and waits for a new order set.
+// If valid, confirms the orders, but keeps listening for input from the player: they may amend
+// their orders by submitting a new set, or retract them, until all players have submitted. Once
+// order gathering stops, sends the player's final order set to the given output channel.
+//
+// While waiting, the player may build up their orders incrementally in a draft, which is validated
+// after every change. If the player times out, the last valid version of their draft is used.
 func (game *Game) gatherAndValidateOrderSet(
 	ctx context.Context,
 	faction PlayerFaction,
+	submissions *orderSubmissions,
 	orderChan chan<- []*Order,
 ) {
+	draft := game.newDraft(faction)
+	defer game.removeDraft(faction)
+
+	var buildPlan *BuildPlan
+	if game.season == SeasonWinter {
+		buildPlan = ptr(game.board.buildPlan(faction))
+	}
+
+	// Valid orders submitted by the player, if hasSubmitted is true.
+	var submitted []*Order
+	hasSubmitted := false
+
+	shouldRequestOrders := true
+
 	for {
-		if succeeded := game.messenger.SendOrderRequest(faction, game.season); !succeeded {
-			orderChan <- nil
-			return
+		if shouldRequestOrders {
+			if succeeded := game.messenger.SendOrderRequest(
+				faction,
+				game.season,
+				draft.orders,
+				buildPlan,
+			); !succeeded {
+				submissions.markDone(faction)
+				orderChan <- nil
+				return
+			}
 		}
 
-		orders, err := game.messenger.AwaitOrders(ctx, faction)
+		input, err := game.awaitOrderSubmission(ctx, faction, draft)
 		if err != nil {
-			err = wrap.Error(err, "failed to receive orders")
-			game.log.Error(ctx, err, "")
-			game.messenger.SendError(faction, err)
-			orderChan <- nil
+			if hasSubmitted {
+				orderChan <- submitted
+			} else {
+				orderChan <- game.handleOrdersNotSubmitted(ctx, faction, err, draft)
+			}
 			return
 		}
 
-		for i := range orders {
-			orders[i].Faction = faction
+		switch input.Type {
+		case OrderInputRetract:
+			if !hasSubmitted {
+				game.messenger.SendError(faction, errors.New("no submitted orders to retract"))
+				shouldRequestOrders = false
+				continue
+			}
+			if ok := submissions.markNotDone(faction); !ok {
+				game.messenger.SendError(
+					faction,
+					errors.New("cannot retract orders after all players have submitted"),
+				)
+				shouldRequestOrders = false
+				continue
+			}
+
+			submitted, hasSubmitted = nil, false
+			game.messenger.SendOrdersRetracted(faction)
+			shouldRequestOrders = true
+		default: // OrderInputSubmit
+			// The draft now holds the submitted orders, so this validates the submission
+			if errs := game.validateDraft(faction, draft); errs != nil {
+				for _, err := range errs {
+					orderValidationFailuresMetric.Inc(errorCode(err).String())
+				}
+				game.log.Error(ctx, errs, "")
+				game.messenger.SendError(faction, errs)
+				// If the player has already submitted valid orders, those still stand
+				shouldRequestOrders = !hasSubmitted
+				continue
+			}
+
+			submitted, hasSubmitted = input.Orders, true
+			game.messenger.SendOrdersConfirmation(faction)
+			submissions.markDone(faction)
+			shouldRequestOrders = false
 		}
+	}
+}
 
-		if err := validateOrders(orders, faction, game.board, game.season); err != nil {
-			game.log.Error(ctx, err, "")
-			game.messenger.SendError(faction, err)
+// Receives order input from the given player until they submit or retract their orders, applying
+// draft changes and answering legal order requests in the meantime. Submitted orders also replace
+// the draft, so that they are kept if rejected or retracted.
+func (game *Game) awaitOrderSubmission(
+	ctx context.Context,
+	faction PlayerFaction,
+	draft *orderDraft,
+) (OrderInput, error) {
+	for {
+		input, err := game.messenger.AwaitOrderInput(ctx, faction)
+		if err != nil {
+			return input, err
+		}
+
+		for _, order := range input.Orders {
+			order.Faction = faction
+		}
+		if input.Order != nil {
+			input.Order.Faction = faction
+		}
+
+		if input.Type == OrderInputLegalOrdersRequest {
+			game.sendLegalOrders(faction, input.Region)
+			continue
+		}
+
+		if err := game.applyToDraft(draft, input); err != nil {
+			game.messenger.SendError(faction, wrap.Error(err, "failed to update order draft"))
 			continue
 		}
 
+		if input.Type == OrderInputSubmit || input.Type == OrderInputRetract {
+			return input, nil
+		}
+
+		errs := game.validateDraft(faction, draft)
+		game.messenger.SendOrderDraft(faction, draft.orders, errs)
+	}
+}
+
+// Sends the legal orders for the given player's unit in the given region, or for all their units
+// if the region is blank.
+func (game *Game) sendLegalOrders(faction PlayerFaction, region RegionName) {
+	orders, err := LegalOrders(
+		game.board,
+		game.UnitTypes,
+		game.treasuryOf(faction),
+		game.season,
+		faction,
+		region,
+	)
+	if err != nil {
+		game.messenger.SendError(faction, wrap.Error(err, "failed to get legal orders"))
+		return
+	}
+	game.messenger.SendLegalOrders(faction, region, orders)
+}
+
+// Informs the given player that order gathering stopped before they submitted orders, with the
+// given error. Returns the last valid version of their order draft if they timed out, or nil
+// otherwise.
+func (game *Game) handleOrdersNotSubmitted(
+	ctx context.Context,
+	faction PlayerFaction,
+	err error,
+	draft *orderDraft,
+) []*Order {
+	err = wrap.Error(handleTimeout(err), "failed to receive orders")
+	game.log.Error(ctx, err, "", "faction", faction)
+	game.messenger.SendError(faction, err)
+
+	if errors.Is(err, errOrdersTimedOut) && draft.lastValid != nil {
+		game.log.Info(ctx, "Using last valid order draft", "faction", faction)
 		game.messenger.SendOrdersConfirmation(faction)
-		orderChan <- orders
+		return draft.lastValid
 	}
+
+	return nil
 }
 
 // Checks if the given set of orders are valid for the state of the board in the given season.
-// Assumes that all orders are from the same faction.
-func validateOrders(orders []*Order, faction PlayerFaction, board Board, season Season) error {
-	var err error
+// Assumes that all orders are from the same faction. The treasury is the faction's money to pay for
+// builds with, or nil if the game does not use the economy rule (see [Rules]).
+//
+// If the orders are invalid, all errors found are returned, so that the player can fix them at
+// once. Each error chain contains an [InputError], tied to the offending order where possible.
+func validateOrders(
+	orders []*Order,
+	faction PlayerFaction,
+	board Board,
+	boardInfo BoardInfo,
+	treasury *int,
+	season Season,
+) OrderValidationErrors {
+	var errs OrderValidationErrors
 	if season == SeasonWinter {
-		err = validateWinterOrders(orders, faction, board)
+		errs = validateWinterOrders(orders, faction, board, boardInfo.UnitTypes, treasury)
 	} else {
-		err = validateNonWinterOrders(orders, board)
+		errs = validateNonWinterOrders(
+			orders,
+			board,
+			boardInfo.UnitTypes,
+			boardInfo.PlayerFactions,
+		)
+	}
+
+	if len(errs) == 0 {
+		return nil
 	}
-	return err
+	return errs
 }
 
-func validateWinterOrders(orders []*Order, faction PlayerFaction, board Board) error {
+func validateWinterOrders(
+	orders []*Order,
+	faction PlayerFaction,
+	board Board,
+	unitTypes UnitTypes,
+	treasury *int,
+) OrderValidationErrors {
 	var disbands set.ArraySet[RegionName]
 	var outgoingMoves set.ArraySet[RegionName]
 	for _, order := range orders {
@@ -219,52 +476,87 @@ func validateWinterOrders(orders []*Order, faction PlayerFaction, board Board) e
 		}
 	}
 
-	for _, order := range orders {
+	var errs OrderValidationErrors
+
+	for i, order := range orders {
 		origin, ok := board[order.Origin]
 		if !ok {
-			return wrap.Error(
-				fmt.Errorf("origin region with name '%s' not found", order.Origin), "invalid order",
+			errs = append(
+				errs,
+				withOrderDetails(
+					wrap.Error(unknownRegionError("origin", order.Origin), "invalid order"),
+					i,
+					order,
+				),
 			)
+			continue
 		}
 
-		if err := validateWinterOrder(order, origin, board, disbands, outgoingMoves); err != nil {
-			return wrap.Errorf(err, "invalid winter order in region '%s'", order.Origin)
+		err := validateWinterOrder(order, origin, board, unitTypes, disbands, outgoingMoves)
+		if err != nil {
+			errs = append(
+				errs,
+				withOrderDetails(
+					wrap.Errorf(err, "invalid winter order in region '%s'", order.Origin),
+					i,
+					order,
+				),
+			)
 		}
 	}
 
-	if err := validateOrderSet(orders); err != nil {
-		return wrap.Error(err, "invalid winter order set")
+	for _, err := range validateOrderSet(orders) {
+		errs = append(errs, wrap.Error(err, "invalid winter order set"))
 	}
 
-	if err := validateNumberOfBuilds(orders, faction, board, disbands); err != nil {
-		return wrap.Error(err, "invalid winter order set")
+	err := validateNumberOfBuilds(orders, faction, board, unitTypes, treasury, disbands)
+	if err != nil {
+		errs = append(errs, wrap.Error(err, "invalid winter order set"))
 	}
 
-	return nil
+	return errs
 }
 
 func validateWinterOrder(
 	order *Order,
 	origin *Region,
 	board Board,
+	unitTypes UnitTypes,
 	disbands set.ArraySet[RegionName],
 	outgoingMoves set.ArraySet[RegionName],
 ) error {
-	if err := validateOrderedUnit(order, origin); err != nil {
+	if err := validateOrderedUnit(order, origin, unitTypes); err != nil {
 		return err
 	}
 
+	if len(order.TransportPath) != 0 || len(order.TransportFactions) != 0 {
+		return newInputError(
+			ErrorCodeInvalidTransportPath,
+			errors.New("transports cannot be used in winter"),
+		)
+	}
+
+	if order.SupportedFaction != "" {
+		return newInputError(
+			ErrorCodeInvalidSupportedFaction,
+			errors.New("supports cannot be given in winter"),
+		)
+	}
+
 	switch order.Type {
 	case OrderMove:
-		return validateWinterMove(order, origin, board, disbands, outgoingMoves)
+		return validateWinterMove(order, origin, board, unitTypes, disbands, outgoingMoves)
 	case OrderBuild:
-		return validateBuild(order, origin, board)
+		return validateBuild(order, origin, board, unitTypes)
 	case OrderDisband:
 		// No extra validation needed - validateOrderedUnit already checks that the ordered region
 		// is not empty, and that its unit matches the submitting player's faction
 		return nil
 	default:
-		return fmt.Errorf("order type '%s' is invalid in winter", order.Type)
+		return newInputError(
+			ErrorCodeInvalidOrderType,
+			fmt.Errorf("order type '%s' is invalid in winter", order.Type),
+		).withMismatch("", order.Type.String())
 	}
 }
 
@@ -272,49 +564,61 @@ func validateWinterMove(
 	order *Order,
 	origin *Region,
 	board Board,
+	unitTypes UnitTypes,
 	disbands set.ArraySet[RegionName],
 	outgoingMoves set.ArraySet[RegionName],
 ) error {
 	if order.Destination == "" {
-		return errors.New("winter move orders must have destination")
+		return newInputError(
+			ErrorCodeMissingDestination,
+			errors.New("winter move orders must have destination"),
+		)
 	}
 
 	destination, ok := board[order.Destination]
 	if !ok {
-		return fmt.Errorf("destination region with name '%s' not found", order.Destination)
+		return unknownRegionError("destination", order.Destination)
 	}
 
 	if destination.ControllingFaction != order.Faction {
-		return errors.New("must control destination region in winter move")
+		return newInputError(
+			ErrorCodeRegionNotControlled,
+			errors.New("must control destination region in winter move"),
+		).withRegion(destination.Name)
 	}
 
 	if !destination.empty() &&
 		!disbands.Contains(destination.Name) &&
 		!outgoingMoves.Contains(destination.Name) {
-		return fmt.Errorf("move destination '%s' already has a unit", destination.Name)
+		return newInputError(
+			ErrorCodeRegionOccupied,
+			fmt.Errorf("move destination '%s' already has a unit", destination.Name),
+		).withRegion(destination.Name)
 	}
 
-	if origin.Unit.Type == UnitShip && !destination.isCoast(board) {
-		return errors.New("ship winter move destination must be coast")
+	if unitTypes[origin.Unit.Type].Naval && !destination.isCoast(board) {
+		return newInputError(
+			ErrorCodeInvalidDestination,
+			errors.New("ship winter move destination must be coast"),
+		).withRegion(destination.Name)
 	}
 
 	return nil
 }
 
-func validateBuild(order *Order, origin *Region, board Board) error {
+func validateBuild(order *Order, origin *Region, board Board, unitTypes UnitTypes) error {
 	if !origin.empty() {
-		return errors.New("cannot build in region already occupied")
+		return newInputError(
+			ErrorCodeRegionOccupied,
+			errors.New("cannot build in region already occupied"),
+		)
 	}
 
-	switch order.UnitType {
-	case UnitShip:
-		if !origin.isCoast(board) {
-			return errors.New("ships can only be built on coast")
-		}
-	case UnitFootman, UnitKnight, UnitCatapult:
-		// Valid
-	default:
-		return errors.New("invalid unit type")
+	if unitTypes[order.UnitType].Naval && !origin.isCoast(board) {
+		return newInputError(
+			ErrorCodeInvalidUnitType,
+			errors.New("ships can only be built on coast"),
+		).withMismatch("", order.UnitType.String())
 	}
 
 	return nil
@@ -324,10 +628,11 @@ func validateNumberOfBuilds(
 	orders []*Order,
 	faction PlayerFaction,
 	board Board,
+	unitTypes UnitTypes,
+	treasury *int,
 	disbands set.ArraySet[RegionName],
 ) error {
-	unitCount, maxUnitCount := board.unitCounts(faction)
-	unitsToBuild := maxUnitCount - unitCount
+	plan := board.buildPlan(faction)
 
 	buildOrderCount := 0
 	for _, order := range orders {
@@ -336,121 +641,254 @@ func validateNumberOfBuilds(
 		}
 	}
 
-	if unitsToBuild < 0 {
-		unitsToDisband := -unitsToBuild
+	if plan.RequiredDisbands > 0 {
 		if buildOrderCount != 0 {
-			return fmt.Errorf(
-				"cannot place build orders when you need to disband units (%d units to disband)",
-				unitsToDisband,
-			)
+			return newInputError(
+				ErrorCodeInvalidBuildCount,
+				fmt.Errorf(
+					"cannot place build orders when you need to disband units (%d units to disband)",
+					plan.RequiredDisbands,
+				),
+			).withMismatch("0", strconv.Itoa(buildOrderCount))
 		}
-		if disbands.Size() != unitsToDisband {
-			return fmt.Errorf(
-				"need to disband %d units, but received %d disband orders",
-				unitsToDisband,
-				disbands.Size(),
-			)
+		if disbands.Size() != plan.RequiredDisbands {
+			return newInputError(
+				ErrorCodeInvalidDisbandCount,
+				fmt.Errorf(
+					"need to disband %d units, but received %d disband orders",
+					plan.RequiredDisbands,
+					disbands.Size(),
+				),
+			).withMismatch(strconv.Itoa(plan.RequiredDisbands), strconv.Itoa(disbands.Size()))
 		}
 		return nil
 	}
 
-	if buildOrderCount > unitsToBuild {
-		return fmt.Errorf(
-			"have %d units to build, but received %d build orders",
-			unitsToBuild,
-			buildOrderCount,
-		)
+	if buildOrderCount > plan.AllowedBuilds {
+		return newInputError(
+			ErrorCodeInvalidBuildCount,
+			fmt.Errorf(
+				"have %d units to build, but received %d build orders",
+				plan.AllowedBuilds,
+				buildOrderCount,
+			),
+		).withMismatch(strconv.Itoa(plan.AllowedBuilds), strconv.Itoa(buildOrderCount))
+	}
+
+	if treasury != nil {
+		if cost := buildCost(orders, unitTypes); cost > *treasury {
+			return newInputError(
+				ErrorCodeInsufficientFunds,
+				fmt.Errorf(
+					"build orders cost %d, but you only have %d in your treasury",
+					cost,
+					*treasury,
+				),
+			).withMismatch(strconv.Itoa(*treasury), strconv.Itoa(cost))
+		}
 	}
 
 	return nil
 }
 
-func validateNonWinterOrders(orders []*Order, board Board) error {
-	for _, order := range orders {
+func validateNonWinterOrders(
+	orders []*Order,
+	board Board,
+	unitTypes UnitTypes,
+	factions []PlayerFaction,
+) OrderValidationErrors {
+	var errs OrderValidationErrors
+
+	// Indices of orders that passed validation on their own, for which we can check move paths
+	var validOrderIndices []int
+
+	for i, order := range orders {
 		origin, ok := board[order.Origin]
 		if !ok {
-			return wrap.Error(
-				fmt.Errorf("origin region with name '%s' not found", order.Origin), "invalid order",
+			errs = append(
+				errs,
+				withOrderDetails(
+					wrap.Error(unknownRegionError("origin", order.Origin), "invalid order"),
+					i,
+					order,
+				),
 			)
+			continue
 		}
 
-		if err := validateNonWinterOrder(order, origin, board); err != nil {
-			return wrap.Errorf(err, "invalid order in region '%s'", order.Origin)
+		if err := validateNonWinterOrder(order, origin, board, unitTypes, factions); err != nil {
+			errs = append(
+				errs,
+				withOrderDetails(
+					wrap.Errorf(err, "invalid order in region '%s'", order.Origin),
+					i,
+					order,
+				),
+			)
+			continue
 		}
+
+		validOrderIndices = append(validOrderIndices, i)
 	}
 
-	if err := validateOrderSet(orders); err != nil {
-		return wrap.Error(err, "invalid order set")
+	setErrs := validateOrderSet(orders)
+	for _, err := range setErrs {
+		errs = append(errs, wrap.Error(err, "invalid order set"))
 	}
 
-	if err := validateReachableMoveDestinations(orders, board); err != nil {
-		return err
+	// Move paths depend on the other orders placed on the board, so we can only check them
+	// reliably if the order set as a whole is valid
+	if len(setErrs) == 0 {
+		errs = append(
+			errs,
+			validateReachableMoveDestinations(orders, validOrderIndices, board, unitTypes)...,
+		)
 	}
 
-	return nil
+	return errs
 }
 
-func validateNonWinterOrder(order *Order, origin *Region, board Board) error {
-	if err := validateOrderedUnit(order, origin); err != nil {
+func validateNonWinterOrder(
+	order *Order,
+	origin *Region,
+	board Board,
+	unitTypes UnitTypes,
+	factions []PlayerFaction,
+) error {
+	if err := validateOrderedUnit(order, origin, unitTypes); err != nil {
 		return err
 	}
 
 	if order.Retreat {
-		return errors.New("retreat orders can only be created by the server")
+		return newInputError(
+			ErrorCodeRetreatNotAllowed,
+			errors.New("retreat orders can only be created by the server"),
+		)
+	}
+
+	if len(order.TransportPath) != 0 && order.Type != OrderMove {
+		return newInputError(
+			ErrorCodeInvalidTransportPath,
+			errors.New("only move orders can have transport paths"),
+		)
+	}
+
+	if len(order.TransportFactions) != 0 && order.Type != OrderTransport {
+		return newInputError(
+			ErrorCodeInvalidTransport,
+			errors.New("only transport orders can name factions to transport"),
+		)
+	}
+
+	if order.SupportedFaction != "" && order.Type != OrderSupport {
+		return newInputError(
+			ErrorCodeInvalidSupportedFaction,
+			errors.New("only support orders can name a faction to support"),
+		)
+	}
+
+	if order.SupportedFaction != "" && !slices.Contains(factions, order.SupportedFaction) {
+		return newInputError(
+			ErrorCodeInvalidSupportedFaction,
+			fmt.Errorf("supported faction '%s' is not in the game", order.SupportedFaction),
+		)
 	}
 
 	switch order.Type {
 	case OrderMove, OrderSupport:
-		return validateMoveOrSupport(order, origin, board)
+		return validateMoveOrSupport(order, origin, board, unitTypes)
 	case OrderBesiege, OrderTransport:
-		return validateBesiegeOrTransport(order, origin)
+		return validateBesiegeOrTransport(order, origin, unitTypes)
+	case OrderHold:
+		return validateHold(order)
 	default:
-		return fmt.Errorf("invalid order type '%s'", order.Type)
+		return newInputError(
+			ErrorCodeInvalidOrderType,
+			fmt.Errorf("invalid order type '%s'", order.Type),
+		).withMismatch("", order.Type.String())
 	}
 }
 
-func validateMoveOrSupport(order *Order, origin *Region, board Board) error {
+func validateMoveOrSupport(
+	order *Order,
+	origin *Region,
+	board Board,
+	unitTypes UnitTypes,
+) error {
 	if order.Destination == "" {
-		return errors.New("moves and supports must have destination")
+		return newInputError(
+			ErrorCodeMissingDestination,
+			errors.New("moves and supports must have destination"),
+		)
 	}
 
 	destination, ok := board[order.Destination]
 	if !ok {
-		return fmt.Errorf("destination region with name '%s' not found", order.Destination)
+		return unknownRegionError("destination", order.Destination)
 	}
 
-	if origin.Unit.Type == UnitShip {
+	if unitTypes[origin.Unit.Type].Naval {
 		if !destination.Sea && !destination.isCoast(board) {
-			return errors.New("ship order destination must be sea or coast")
+			return newInputError(
+				ErrorCodeInvalidDestination,
+				errors.New("ship order destination must be sea or coast"),
+			).withRegion(destination.Name)
 		}
 	} else {
 		if destination.Sea {
-			return errors.New("only ships can order to seas")
+			return newInputError(
+				ErrorCodeInvalidDestination,
+				errors.New("only ships can order to seas"),
+			).withRegion(destination.Name)
 		}
 	}
 
 	switch order.Type {
 	case OrderMove:
-		return validateMove(order, origin, board)
+		return validateMove(order, origin, board, unitTypes)
 	case OrderSupport:
 		return validateSupport(order, origin)
 	default:
-		return errors.New("invalid order type")
+		return newInputError(ErrorCodeInvalidOrderType, errors.New("invalid order type"))
 	}
 }
 
-func validateMove(order *Order, origin *Region, board Board) error {
+func validateMove(order *Order, origin *Region, board Board, unitTypes UnitTypes) error {
 	if order.SecondDestination != "" {
-		if origin.Unit.Type != UnitKnight {
-			return errors.New(
-				"second destinations for move orders can only be applied to knight units",
+		if unitTypes[origin.Unit.Type].MoveRange < 2 {
+			return newInputError(
+				ErrorCodeSecondDestinationNotAllowed,
+				fmt.Errorf(
+					"second destinations for move orders can only be applied to units with a "+
+						"move range of 2, which '%s' units do not have",
+					unitTypes[origin.Unit.Type].Name,
+				),
 			)
 		}
 
 		if _, ok := board[order.SecondDestination]; !ok {
-			return fmt.Errorf(
-				"second destination region with name '%s' not found", order.SecondDestination,
-			)
+			return unknownRegionError("second destination", order.SecondDestination)
+		}
+	}
+
+	for i, regionName := range order.TransportPath {
+		region, ok := board[regionName]
+		if !ok {
+			return unknownRegionError("transport path", regionName)
+		}
+
+		if !region.Sea {
+			return newInputError(
+				ErrorCodeInvalidTransportPath,
+				fmt.Errorf("transport path region '%s' is not a sea", regionName),
+			).withRegion(regionName)
+		}
+
+		if slices.Contains(order.TransportPath[:i], regionName) {
+			return newInputError(
+				ErrorCodeInvalidTransportPath,
+				fmt.Errorf("transport path goes through '%s' more than once", regionName),
+			).withRegion(regionName)
 		}
 	}
 
@@ -459,124 +897,196 @@ func validateMove(order *Order, origin *Region, board Board) error {
 
 func validateSupport(order *Order, origin *Region) error {
 	if !origin.adjacentTo(order.Destination) {
-		return errors.New("support order must be adjacent to destination")
+		return newInputError(
+			ErrorCodeNotAdjacent,
+			errors.New("support order must be adjacent to destination"),
+		).withRegion(order.Destination)
 	}
 
 	return nil
 }
 
-func validateBesiegeOrTransport(order *Order, origin *Region) error {
+func validateBesiegeOrTransport(order *Order, origin *Region, unitTypes UnitTypes) error {
 	if order.Destination != "" {
-		return errors.New("besiege or transport orders cannot have destination")
+		return newInputError(
+			ErrorCodeUnexpectedDestination,
+			errors.New("besiege or transport orders cannot have destination"),
+		)
 	}
 
 	switch order.Type {
 	case OrderBesiege:
-		return validateBesiege(origin)
+		return validateBesiege(origin, unitTypes)
 	case OrderTransport:
-		return validateTransport(origin)
+		return validateTransport(origin, unitTypes)
 	default:
-		return errors.New("invalid order type")
+		return newInputError(ErrorCodeInvalidOrderType, errors.New("invalid order type"))
+	}
+}
+
+func validateHold(order *Order) error {
+	if order.Destination != "" || order.SecondDestination != "" {
+		return newInputError(
+			ErrorCodeUnexpectedDestination,
+			errors.New("hold orders cannot have destination"),
+		)
 	}
+
+	return nil
 }
 
-func validateBesiege(origin *Region) error {
+func validateBesiege(origin *Region, unitTypes UnitTypes) error {
 	if !origin.Castle {
-		return errors.New("besieged region must have castle")
+		return newInputError(
+			ErrorCodeInvalidBesiege,
+			errors.New("besieged region must have castle"),
+		)
 	}
 
 	if origin.controlled() {
-		return errors.New("besieged region cannot already be controlled")
+		return newInputError(
+			ErrorCodeInvalidBesiege,
+			errors.New("besieged region cannot already be controlled"),
+		)
 	}
 
-	if origin.Unit.Type == UnitShip {
-		return errors.New("ships cannot besiege")
+	if unitTypes[origin.Unit.Type].Naval {
+		return newInputError(ErrorCodeInvalidBesiege, errors.New("ships cannot besiege"))
 	}
 
 	return nil
 }
 
-func validateTransport(origin *Region) error {
-	if origin.Unit.Type != UnitShip {
-		return errors.New("only ships can transport")
+func validateTransport(origin *Region, unitTypes UnitTypes) error {
+	if !unitTypes[origin.Unit.Type].Naval {
+		return newInputError(ErrorCodeInvalidTransport, errors.New("only ships can transport"))
 	}
 
 	if !origin.Sea {
-		return errors.New("transport orders can only be placed at sea")
+		return newInputError(
+			ErrorCodeInvalidTransport,
+			errors.New("transport orders can only be placed at sea"),
+		)
 	}
 
 	return nil
 }
 
-func validateReachableMoveDestinations(orders []*Order, board Board) error {
+// Checks that the move orders at the given indices can reach their destinations, given the other
+// valid orders.
+func validateReachableMoveDestinations(
+	orders []*Order,
+	validOrderIndices []int,
+	board Board,
+	unitTypes UnitTypes,
+) []error {
+	validOrders := make([]*Order, 0, len(validOrderIndices))
+	for _, i := range validOrderIndices {
+		validOrders = append(validOrders, orders[i])
+	}
+
 	// Copy the board, so orders do not persist
 	board = board.copy()
-	board.placeOrders(orders)
+	board.placeOrders(validOrders)
 
-	for _, order := range orders {
+	var errs []error
+	for _, i := range validOrderIndices {
+		order := orders[i]
 		if order.Type != OrderMove {
 			continue
 		}
 
-		if err := validateReachableMoveDestination(order, board); err != nil {
-			return wrap.Errorf(
-				err, "invalid move from '%s' to '%s'", order.Origin, order.Destination,
+		if err := validateReachableMoveDestination(order, board, unitTypes); err != nil {
+			errs = append(
+				errs,
+				withOrderDetails(
+					wrap.Errorf(
+						err, "invalid move from '%s' to '%s'", order.Origin, order.Destination,
+					),
+					i,
+					order,
+				),
 			)
+			continue
 		}
 
 		if order.hasKnightMove() {
-			if err := validateReachableMoveDestination(order.knightMove(), board); err != nil {
-				return wrap.Errorf(
-					err,
-					"invalid second destination for knight move from '%s' to '%s'",
-					order.Origin,
-					order.SecondDestination,
+			err := validateReachableMoveDestination(order.knightMove(), board, unitTypes)
+			if err != nil {
+				errs = append(
+					errs,
+					withOrderDetails(
+						wrap.Errorf(
+							err,
+							"invalid second destination for knight move from '%s' to '%s'",
+							order.Origin,
+							order.SecondDestination,
+						),
+						i,
+						order,
+					),
 				)
 			}
 		}
 	}
 
-	return nil
+	return errs
 }
 
-func validateReachableMoveDestination(move *Order, board Board) error {
+func validateReachableMoveDestination(move *Order, board Board, unitTypes UnitTypes) error {
 	origin := board[move.Origin]
 
+	if len(move.TransportPath) != 0 {
+		if origin.adjacentTo(move.Destination) {
+			return newInputError(
+				ErrorCodeInvalidTransportPath,
+				errors.New("moves to adjacent regions cannot have transport paths"),
+			)
+		}
+
+		_, _, err := board.followTransportPath(move, unitTypes, true)
+		return err
+	}
+
 	if !origin.adjacentTo(move.Destination) {
-		canTransport, _, _ := board.findTransportPath(move.Origin, move.Destination)
+		canTransport, _, _ := board.findTransportPath(move.Origin, move.Destination, unitTypes)
 
 		if !canTransport {
-			return errors.New("regions not adjacent, and no transport path available")
+			return newInputError(
+				ErrorCodeUnreachableDestination,
+				errors.New("regions not adjacent, and no transport path available"),
+			).withRegion(move.Destination)
 		}
 	}
 
 	return nil
 }
 
-func validateOrderSet(orders []*Order) error {
-	if err := validateUniqueMoveDestinations(orders); err != nil {
-		return err
-	}
-
-	if err := validateOneOrderPerRegion(orders); err != nil {
-		return err
-	}
-
-	return nil
+func validateOrderSet(orders []*Order) []error {
+	errs := validateUniqueMoveDestinations(orders)
+	errs = append(errs, validateOneOrderPerRegion(orders)...)
+	return errs
 }
 
-func validateUniqueMoveDestinations(orders []*Order) error {
+func validateUniqueMoveDestinations(orders []*Order) []error {
 	moveDestinations := set.ArraySetWithCapacity[RegionName](len(orders))
 
-	for _, order := range orders {
+	var errs []error
+	for i, order := range orders {
 		if order.Type == OrderMove {
 			if moveDestinations.Contains(order.Destination) {
-				return fmt.Errorf("orders include two moves to region '%s'", order.Destination)
+				errs = append(
+					errs,
+					withOrderDetails(duplicateMoveDestinationError(order.Destination), i, order),
+				)
 			}
 
 			if order.SecondDestination != "" && moveDestinations.Contains(order.SecondDestination) {
-				return fmt.Errorf(
-					"orders include two moves to region '%s'", order.SecondDestination,
+				errs = append(
+					errs,
+					withOrderDetails(
+						duplicateMoveDestinationError(order.SecondDestination), i, order,
+					),
 				)
 			}
 
@@ -584,49 +1094,88 @@ func validateUniqueMoveDestinations(orders []*Order) error {
 		}
 	}
 
-	return nil
+	return errs
+}
+
+func duplicateMoveDestinationError(destination RegionName) error {
+	return newInputError(
+		ErrorCodeDuplicateMoveDestination,
+		fmt.Errorf("orders include two moves to region '%s'", destination),
+	).withRegion(destination)
 }
 
-func validateOneOrderPerRegion(orders []*Order) error {
+func validateOneOrderPerRegion(orders []*Order) []error {
 	orderedRegions := set.ArraySetWithCapacity[RegionName](len(orders))
 
-	for _, order := range orders {
+	var errs []error
+	for i, order := range orders {
 		if orderedRegions.Contains(order.Origin) {
-			return fmt.Errorf("unit in region '%s' is ordered twice", order.Origin)
+			errs = append(
+				errs,
+				withOrderDetails(
+					newInputError(
+						ErrorCodeDuplicateOrder,
+						fmt.Errorf("unit in region '%s' is ordered twice", order.Origin),
+					),
+					i,
+					order,
+				),
+			)
 		}
 
 		orderedRegions.Add(order.Origin)
 	}
 
-	return nil
+	return errs
 }
 
-func validateOrderedUnit(order *Order, origin *Region) error {
-	if !order.UnitType.isValid() {
-		return fmt.Errorf("invalid ordered unit type '%d'", order.UnitType)
+func validateOrderedUnit(order *Order, origin *Region, unitTypes UnitTypes) error {
+	if !unitTypes.contains(order.UnitType) {
+		return newInputError(
+			ErrorCodeInvalidUnitType,
+			fmt.Errorf("invalid ordered unit type '%d'", order.UnitType),
+		).withMismatch("", strconv.Itoa(int(order.UnitType)))
 	}
 
 	if order.Type != OrderBuild {
 		if origin.empty() {
-			return errors.New("ordered region does not have a unit")
+			return newInputError(
+				ErrorCodeNoUnitInRegion,
+				errors.New("ordered region does not have a unit"),
+			)
 		}
 
 		if origin.Unit.Faction != order.Faction {
-			return fmt.Errorf(
-				"faction of ordered unit '%s' does not match your faction '%s'",
-				origin.Unit.Faction,
-				order.Faction,
-			)
+			return newInputError(
+				ErrorCodeFactionMismatch,
+				fmt.Errorf(
+					"faction of ordered unit '%s' does not match your faction '%s'",
+					origin.Unit.Faction,
+					order.Faction,
+				),
+			).withMismatch(string(order.Faction), string(origin.Unit.Faction))
 		}
 
 		if origin.Unit.Type != order.UnitType {
-			return fmt.Errorf(
-				"order unit type '%v' does not match unit type '%v' in ordered region",
-				order.UnitType,
-				origin.Unit.Type,
-			)
+			return newInputError(
+				ErrorCodeUnitTypeMismatch,
+				fmt.Errorf(
+					"order unit type '%v' does not match unit type '%v' in ordered region",
+					order.UnitType,
+					origin.Unit.Type,
+				),
+			).withMismatch(origin.Unit.Type.String(), order.UnitType.String())
 		}
 	}
 
 	return nil
 }
+
+// Returns an error for an order referring to a region that is not on the board. The given kind
+// describes the region's role in the order, e.g. "destination".
+func unknownRegionError(kind string, regionName RegionName) error {
+	return newInputError(
+		ErrorCodeUnknownRegion,
+		fmt.Errorf("%s region with name '%s' not found", kind, regionName),
+	).withRegion(regionName)
+}
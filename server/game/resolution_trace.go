@@ -0,0 +1,182 @@
+package game
+
+import "hermannm.dev/enumnames"
+
+// An event in the resolution of a round, explaining what happened to an order or unit, and why.
+// The events of a round are sent to players in order as a round report, once the round is resolved.
+type ResolutionEvent struct {
+	Type  ResolutionEventType
+	Cause ResolutionCause
+
+	// The order that the event concerns. Nil if the event is not caused by an order, e.g. when a
+	// defending unit is killed.
+	Order *Order `json:",omitempty"`
+
+	// The region where the event took place.
+	Region RegionName
+}
+
+// The events from resolving the current round, in the order they happened.
+type resolutionTrace []ResolutionEvent
+
+func (trace *resolutionTrace) add(
+	eventType ResolutionEventType,
+	cause ResolutionCause,
+	order *Order,
+	region RegionName,
+) {
+	*trace = append(
+		*trace,
+		ResolutionEvent{Type: eventType, Cause: cause, Order: order, Region: region},
+	)
+}
+
+// Identifies what happened in a [ResolutionEvent].
+//
+// Values are part of the protocol between client and server, so they must never change once
+// released. New values must be given a new, unused number.
+type ResolutionEventType uint8
+
+const (
+	// A move order reached its destination, and the unit moved there.
+	ResolutionEventMoveSucceeded ResolutionEventType = 1
+
+	// A move order failed, and the moving unit was killed.
+	ResolutionEventMoveKilled ResolutionEventType = 2
+
+	// A move order failed, and the moving unit returned to its origin (or has to fight its way back
+	// there, if the origin was attacked).
+	ResolutionEventMoveRetreated ResolutionEventType = 3
+
+	// A support order was cut, and will not support any battle.
+	ResolutionEventSupportCut ResolutionEventType = 4
+
+	// A move order was found to be part of a cycle of moves, which all succeed together unless one
+	// of the regions in the cycle is attacked from outside.
+	ResolutionEventMoveCycleFound ResolutionEventType = 5
+
+	// A unit that did not move was killed after losing a battle in its region.
+	ResolutionEventUnitKilled ResolutionEventType = 6
+
+	// A besieging unit increased the siege count of its region.
+	ResolutionEventSiegeProgressed ResolutionEventType = 7
+
+	// A besieging unit completed its siege, and its faction took control of the castle.
+	ResolutionEventCastleConquered ResolutionEventType = 8
+
+	// A unit was built in winter.
+	ResolutionEventUnitBuilt ResolutionEventType = 9
+
+	// A unit was disbanded. The cause tells why: [ResolutionCauseUncontested] for a disband order in
+	// winter, or [ResolutionCauseNoRetreatDestination] or [ResolutionCauseRetreatConflict] for a
+	// unit that failed to retreat after a battle (with the retreat choice rule, see [Rules]).
+	ResolutionEventUnitDisbanded ResolutionEventType = 10
+
+	// A unit that had to retreat after a battle moved to the region chosen by its player.
+	ResolutionEventUnitRetreated ResolutionEventType = 11
+)
+
+var resolutionEventNames = enumnames.NewMap(
+	map[ResolutionEventType]string{
+		ResolutionEventMoveSucceeded:   "MoveSucceeded",
+		ResolutionEventMoveKilled:      "MoveKilled",
+		ResolutionEventMoveRetreated:   "MoveRetreated",
+		ResolutionEventSupportCut:      "SupportCut",
+		ResolutionEventMoveCycleFound:  "MoveCycleFound",
+		ResolutionEventUnitKilled:      "UnitKilled",
+		ResolutionEventSiegeProgressed: "SiegeProgressed",
+		ResolutionEventCastleConquered: "CastleConquered",
+		ResolutionEventUnitBuilt:       "UnitBuilt",
+		ResolutionEventUnitDisbanded:   "UnitDisbanded",
+		ResolutionEventUnitRetreated:   "UnitRetreated",
+	},
+)
+
+func (eventType ResolutionEventType) String() string {
+	return resolutionEventNames.GetNameOrFallback(eventType, "INVALID")
+}
+
+// Returns all valid resolution event types (used for generating protocol schemas).
+func (ResolutionEventType) Values() []ResolutionEventType {
+	return resolutionEventNames.Keys()
+}
+
+// Identifies why a [ResolutionEvent] happened.
+//
+// Values are part of the protocol between client and server, so they must never change once
+// released. New values must be given a new, unused number.
+type ResolutionCause uint8
+
+const (
+	// The order met no resistance, e.g. a move to an empty region controlled by a player, or an
+	// order in winter.
+	ResolutionCauseUncontested ResolutionCause = 1
+
+	// The order's faction won a battle.
+	ResolutionCauseWonBattle ResolutionCause = 2
+
+	// The order's faction lost a battle.
+	ResolutionCauseLostBattle ResolutionCause = 3
+
+	// The battle was a tie.
+	ResolutionCauseTiedBattle ResolutionCause = 4
+
+	// The order's faction lost a border battle (two moves against each other's regions).
+	ResolutionCauseLostBorderBattle ResolutionCause = 5
+
+	// The border battle was a tie.
+	ResolutionCauseTiedBorderBattle ResolutionCause = 6
+
+	// The move needed transport by ship, but there was no transport path to the destination, e.g.
+	// because a transporting ship lost a battle.
+	ResolutionCauseNoTransportPath ResolutionCause = 7
+
+	// The order failed to cross a danger zone.
+	ResolutionCauseDangerZone ResolutionCause = 8
+
+	// The order was part of a cycle of moves.
+	ResolutionCauseMoveCycle ResolutionCause = 9
+
+	// The supporting unit's region was attacked by a move.
+	ResolutionCauseOriginAttacked ResolutionCause = 10
+
+	// The supporting unit's region was attacked by a knight's second move.
+	ResolutionCauseKnightMoveAttack ResolutionCause = 11
+
+	// The unit besieged its region.
+	ResolutionCauseSiege ResolutionCause = 12
+
+	// The unit had to retreat after a battle, but had no region to retreat to.
+	ResolutionCauseNoRetreatDestination ResolutionCause = 13
+
+	// The unit retreated to the same region as another retreating unit.
+	ResolutionCauseRetreatConflict ResolutionCause = 14
+)
+
+var resolutionCauseNames = enumnames.NewMap(
+	map[ResolutionCause]string{
+		ResolutionCauseUncontested:          "Uncontested",
+		ResolutionCauseWonBattle:            "WonBattle",
+		ResolutionCauseLostBattle:           "LostBattle",
+		ResolutionCauseTiedBattle:           "TiedBattle",
+		ResolutionCauseLostBorderBattle:     "LostBorderBattle",
+		ResolutionCauseTiedBorderBattle:     "TiedBorderBattle",
+		ResolutionCauseNoTransportPath:      "NoTransportPath",
+		ResolutionCauseDangerZone:           "DangerZone",
+		ResolutionCauseMoveCycle:            "MoveCycle",
+		ResolutionCauseOriginAttacked:       "OriginAttacked",
+		ResolutionCauseKnightMoveAttack:     "KnightMoveAttack",
+		ResolutionCauseSiege:                "Siege",
+		ResolutionCauseNoRetreatDestination: "NoRetreatDestination",
+		ResolutionCauseRetreatConflict:      "RetreatConflict",
+	},
+)
+
+func (cause ResolutionCause) String() string {
+	return resolutionCauseNames.GetNameOrFallback(cause, "INVALID")
+}
+
+// Returns all valid resolution causes (used for generating protocol schemas).
+func (ResolutionCause) Values() []ResolutionCause {
+	return resolutionCauseNames.Keys()
+}
@@ -0,0 +1,188 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"hermannm.dev/wrap"
+)
+
+// Sent to a player whose unit must retreat after a battle, in games with the retreat choice rule
+// (see [Rules]).
+type RetreatRequest struct {
+	// The move that lost or tied a battle. Its unit retreats from the move's origin.
+	Move *Order
+
+	// The regions that the unit can retreat to. The player must choose one of these.
+	Destinations []RegionName
+}
+
+// Sends the unit of the given move back to its origin. With the retreat choice rule (see [Rules]),
+// the unit is instead taken off the board until the end of the round, when its player chooses where
+// it retreats to.
+func (game *Game) retreatMove(move *Order, cause ResolutionCause) {
+	// Retreats created by the server have no unit left on the board, so they are handled the same
+	// way regardless of rules
+	if !game.rules.RetreatChoice || move.Retreat {
+		game.board.retreatMove(move, &game.trace, cause)
+		return
+	}
+
+	game.trace.add(ResolutionEventMoveRetreated, cause, move, move.Destination)
+	game.board.removeOrder(move)
+	game.board[move.Origin].removeUnit()
+
+	if move.hasKnightMove() {
+		game.board[move.SecondDestination].expectedKnightMoves--
+	}
+
+	game.pendingRetreats = append(game.pendingRetreats, move)
+}
+
+// Asks players where to retreat their units from moves that lost or tied battles this round, then
+// places the units there. Units with nowhere to retreat to are disbanded, as are units that retreat
+// to the same region as another unit.
+func (game *Game) resolveRetreats(ctx context.Context) {
+	if len(game.pendingRetreats) == 0 {
+		return
+	}
+
+	requestsByFaction := make(map[PlayerFaction][]RetreatRequest)
+	for _, move := range game.pendingRetreats {
+		destinations := game.board.retreatDestinations(move, game.UnitTypes)
+		if len(destinations) == 0 {
+			game.trace.add(
+				ResolutionEventUnitDisbanded,
+				ResolutionCauseNoRetreatDestination,
+				move,
+				move.Origin,
+			)
+			continue
+		}
+
+		request := RetreatRequest{Move: move, Destinations: destinations}
+		requestsByFaction[move.Faction] = append(requestsByFaction[move.Faction], request)
+	}
+
+	ctx, cleanup := newPlayerInputContext(ctx)
+	defer cleanup()
+
+	chosenDestinations := make(map[*Order]RegionName, len(game.pendingRetreats))
+	var destinationsLock sync.Mutex
+	var waitGroup sync.WaitGroup
+
+	for faction, requests := range requestsByFaction {
+		for _, request := range requests {
+			game.messenger.SendRetreatRequest(faction, request)
+		}
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+
+			for _, request := range requests {
+				destination := game.awaitRetreat(ctx, faction, request)
+
+				destinationsLock.Lock()
+				chosenDestinations[request.Move] = destination
+				destinationsLock.Unlock()
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+
+	retreatsByDestination := make(map[RegionName]int, len(chosenDestinations))
+	for _, destination := range chosenDestinations {
+		retreatsByDestination[destination]++
+	}
+
+	// Goes through pending retreats in order, so that the trace is the same on every run
+	for _, move := range game.pendingRetreats {
+		destination, ok := chosenDestinations[move]
+		if !ok {
+			continue
+		}
+
+		if retreatsByDestination[destination] > 1 {
+			game.trace.add(
+				ResolutionEventUnitDisbanded,
+				ResolutionCauseRetreatConflict,
+				move,
+				destination,
+			)
+			continue
+		}
+
+		game.trace.add(ResolutionEventUnitRetreated, ResolutionCauseUncontested, move, destination)
+		game.board[destination].replaceUnit(move.unit())
+	}
+}
+
+// Waits for the given faction to choose a destination for the given retreat. If the player fails to
+// respond in time or chooses an invalid destination, the unit retreats to the first of the
+// request's destinations (its origin, if available).
+func (game *Game) awaitRetreat(
+	ctx context.Context,
+	faction PlayerFaction,
+	request RetreatRequest,
+) RegionName {
+	destination, err := game.messenger.AwaitRetreat(ctx, faction, request.Move.Origin)
+	if err == nil && !slices.Contains(request.Destinations, destination) {
+		err = newInputError(
+			ErrorCodeInvalidRetreatDestination,
+			fmt.Errorf("unit cannot retreat to '%s'", destination),
+		).withRegion(request.Move.Origin)
+	}
+
+	if err != nil {
+		err = wrap.Error(handleTimeout(err), "failed to receive retreat destination")
+		game.messenger.SendError(faction, err)
+		game.log.WarnError(ctx, err, "", "from", faction, "retreatingFrom", request.Move.Origin)
+		return request.Destinations[0]
+	}
+
+	return destination
+}
+
+// Returns the regions that the unit of the given failed move can retreat to: its origin and the
+// origin's neighbors, excluding the region it attacked. Destinations must have no unit, and land
+// regions must be controlled by the unit's faction. Naval units can only retreat to sea or coast,
+// and land units cannot retreat to sea.
+func (board Board) retreatDestinations(move *Order, unitTypes UnitTypes) []RegionName {
+	origin := board[move.Origin]
+	naval := unitTypes[move.UnitType].Naval
+
+	candidates := []*Region{origin}
+	for _, neighbor := range origin.Neighbors {
+		candidates = append(candidates, board[neighbor.Name])
+	}
+
+	destinations := make([]RegionName, 0, len(candidates))
+	for _, region := range candidates {
+		if region.Name == move.Destination ||
+			!region.empty() ||
+			slices.Contains(destinations, region.Name) {
+			continue
+		}
+
+		if region.Sea {
+			if !naval {
+				continue
+			}
+		} else {
+			if region.ControllingFaction != move.Faction {
+				continue
+			}
+			if naval && !region.isCoast(board) {
+				continue
+			}
+		}
+
+		destinations = append(destinations, region.Name)
+	}
+
+	return destinations
+}
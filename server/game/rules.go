@@ -0,0 +1,20 @@
+package game
+
+// Optional rule variants for a game. The zero value plays by the standard rules.
+type Rules struct {
+	// If true: when a move loses a singleplayer battle or ties a battle, its player chooses where
+	// the unit retreats to (see [RetreatRequest]), instead of the unit returning to its origin.
+	// Retreats are resolved after all other orders, and units with nowhere to retreat to are
+	// disbanded.
+	RetreatChoice bool
+
+	// Bonus added to a defending unit's battle result if the unit has a hold order. If 0, hold
+	// orders only tell other players that the unit stays.
+	HoldDefenseBonus int
+
+	// If true: at the start of every round, each faction earns income from the regions and castles
+	// it controls, which is added to its treasury. Building a unit in winter costs the
+	// [UnitTypeProperties.BuildCost] of its type, and factions can only give build orders that they
+	// can pay for from their treasury (in addition to the usual limit on their number of units).
+	Economy bool
+}
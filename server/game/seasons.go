@@ -23,6 +23,11 @@ func (season Season) String() string {
 	return seasonNames.GetNameOrFallback(season, "INVALID")
 }
 
+// Returns all valid seasons (used for generating protocol schemas).
+func (Season) Values() []Season {
+	return seasonNames.Keys()
+}
+
 func (season Season) next() Season {
 	switch season {
 	case SeasonWinter:
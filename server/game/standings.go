@@ -0,0 +1,111 @@
+package game
+
+import "slices"
+
+// A faction's progress in the game, sent to players after each round.
+type Standing struct {
+	Faction PlayerFaction
+
+	// The number of castle regions controlled by the faction. The first faction to control
+	// [BoardInfo.WinningCastleCount] castles (without a tie) wins the game.
+	CastleCount int
+
+	// The number of regions controlled by the faction.
+	RegionCount int
+
+	// The number of units the faction has on the board.
+	UnitCount int
+
+	// Nations where the faction controls every region, including their home nation.
+	NationsControlled []string
+
+	// The number of castles the faction must take to reach the winning castle count. 0 if they have
+	// already reached it.
+	CastlesToWin int
+
+	// The amount that the faction earns at the start of each round from the regions it currently
+	// controls. 0 if the game does not use the economy rule (see [Rules]).
+	Income int
+
+	// The amount that the faction has to spend on building units. 0 if the game does not use the
+	// economy rule.
+	Treasury int
+
+	// True if this is another faction's standing in a game with fog of war. In that case, only
+	// CastleCount and CastlesToWin are filled in, and the other counts are 0 (see
+	// [Visibility.FilterStandings]).
+	Hidden bool `json:",omitempty"`
+}
+
+// Returns the standings of each player faction on the board, in the same order as
+// [BoardInfo.PlayerFactions].
+func (game *Game) standings() []Standing {
+	castleCounts := game.board.castleCounts()
+
+	var income map[PlayerFaction]int
+	if game.rules.Economy {
+		income = game.board.income()
+	}
+
+	regionCounts := make(map[PlayerFaction]int)
+	unitCounts := make(map[PlayerFaction]int)
+	// Maps nations to the faction that controls all their regions, or blank if none do
+	nationControl := make(map[string]PlayerFaction)
+
+	for _, region := range game.board {
+		if region.controlled() {
+			regionCounts[region.ControllingFaction]++
+		}
+		if !region.empty() {
+			unitCounts[region.Unit.Faction]++
+		}
+
+		if region.Sea {
+			continue
+		}
+		if controllingFaction, ok := nationControl[region.Nation]; !ok {
+			nationControl[region.Nation] = region.ControllingFaction
+		} else if controllingFaction != region.ControllingFaction {
+			nationControl[region.Nation] = ""
+		}
+	}
+
+	standings := make([]Standing, 0, len(game.PlayerFactions))
+	for _, faction := range game.PlayerFactions {
+		nationsControlled := []string{}
+		for nation, controllingFaction := range nationControl {
+			if controllingFaction == faction {
+				nationsControlled = append(nationsControlled, nation)
+			}
+		}
+		slices.Sort(nationsControlled)
+
+		standings = append(
+			standings,
+			Standing{
+				Faction:           faction,
+				CastleCount:       castleCounts[faction],
+				RegionCount:       regionCounts[faction],
+				UnitCount:         unitCounts[faction],
+				NationsControlled: nationsControlled,
+				CastlesToWin:      max(game.WinningCastleCount-castleCounts[faction], 0),
+				Income:            income[faction],
+				Treasury:          game.treasury[faction],
+				Hidden:            false,
+			},
+		)
+	}
+
+	return standings
+}
+
+// Returns the number of castle regions controlled by each faction.
+func (board Board) castleCounts() map[PlayerFaction]int {
+	castleCounts := make(map[PlayerFaction]int)
+	for _, region := range board {
+		if region.Castle && region.controlled() {
+			castleCounts[region.ControllingFaction]++
+		}
+	}
+	return castleCounts
+}
@@ -1,16 +1,23 @@
 package game
 
 import (
+	"context"
+	"fmt"
+
 	"hermannm.dev/set"
 )
 
-func (board Board) resolveUncontestedTransports(region *Region) (mustWait bool) {
+func (board Board) resolveUncontestedTransports(
+	region *Region,
+	unitTypes UnitTypes,
+	trace *resolutionTrace,
+) (mustWait bool) {
 	if region.transportsResolved {
 		return false
 	}
 
 	for _, move := range region.incomingMoves {
-		attacked, dangerZone := board.resolveTransport(move, region)
+		attacked, dangerZone := board.resolveTransport(move, region, unitTypes, trace)
 		if attacked || dangerZone != "" {
 			return true
 		}
@@ -20,14 +27,22 @@ func (board Board) resolveUncontestedTransports(region *Region) (mustWait bool)
 	return false
 }
 
-func (game *Game) resolveContestedTransports(region *Region) (mustWait bool) {
+func (game *Game) resolveContestedTransports(
+	ctx context.Context,
+	region *Region,
+) (mustWait bool) {
 	if region.transportsResolved {
 		return false
 	}
 
 	var dangerZoneCrossings []Battle
 	for _, move := range region.incomingMoves {
-		attacked, dangerZone := game.board.resolveTransport(move, region)
+		attacked, dangerZone := game.board.resolveTransport(
+			move,
+			region,
+			game.UnitTypes,
+			&game.trace,
+		)
 		if attacked {
 			return true
 		}
@@ -41,7 +56,7 @@ func (game *Game) resolveContestedTransports(region *Region) (mustWait bool) {
 	}
 
 	for _, crossing := range dangerZoneCrossings {
-		game.resolveDangerZoneCrossing(crossing)
+		game.resolveDangerZoneCrossing(ctx, crossing)
 	}
 
 	region.transportsResolved = true
@@ -51,17 +66,29 @@ func (game *Game) resolveContestedTransports(region *Region) (mustWait bool) {
 func (board Board) resolveTransport(
 	move *Order,
 	destination *Region,
+	unitTypes UnitTypes,
+	trace *resolutionTrace,
 ) (transportsAttacked bool, dangerZone DangerZone) {
 	if destination.adjacentTo(move.Origin) {
 		return false, ""
 	}
 
+	if len(move.TransportPath) != 0 {
+		transportAttacked, dangerZone, err := board.followTransportPath(move, unitTypes, false)
+		if err != nil {
+			board.retreatMove(move, trace, ResolutionCauseNoTransportPath)
+			return false, ""
+		}
+		return transportAttacked, dangerZone
+	}
+
 	canTransport, transportAttacked, dangerZone := board.findTransportPath(
 		move.Origin,
 		move.Destination,
+		unitTypes,
 	)
 	if !canTransport {
-		board.retreatMove(move)
+		board.retreatMove(move, trace, ResolutionCauseNoTransportPath)
 		return false, ""
 	}
 
@@ -72,13 +99,75 @@ func (board Board) resolveTransport(
 func (board Board) findTransportPath(
 	originName RegionName,
 	destinationName RegionName,
+	unitTypes UnitTypes,
 ) (canTransport bool, transportAttacked bool, dangerZone DangerZone) {
 	origin := board[originName]
-	if origin.empty() || origin.Unit.Type == UnitShip || origin.Sea {
+	if origin.empty() || unitTypes[origin.Unit.Type].Naval || origin.Sea {
 		return false, false, ""
 	}
 
-	return board.recursivelyFindTransportPath(origin, destinationName, &set.ArraySet[RegionName]{})
+	return board.recursivelyFindTransportPath(
+		origin,
+		destinationName,
+		origin.Unit.Faction,
+		&set.ArraySet[RegionName]{},
+	)
+}
+
+// Checks that the given move's explicit transport path (see [Order.TransportPath]) is a chain of
+// adjacent ships that transport the move's faction, from the move's origin to its destination.
+// If the last ship can reach the destination across several danger zones, the one given by the
+// move's ViaDangerZone is used.
+//
+// When validating a single faction's orders, the orders of other factions' ships are not yet known.
+// In that case, trustOtherFactions should be true, so that any ship of another faction counts as a
+// transport.
+func (board Board) followTransportPath(
+	move *Order,
+	unitTypes UnitTypes,
+	trustOtherFactions bool,
+) (transportAttacked bool, dangerZone DangerZone, err error) {
+	previous := board[move.Origin]
+
+	for _, regionName := range move.TransportPath {
+		transportRegion := board[regionName]
+
+		if !previous.adjacentTo(regionName) {
+			return false, "", newInputError(
+				ErrorCodeInvalidTransportPath,
+				fmt.Errorf(
+					"transport path region '%s' is not adjacent to '%s'",
+					regionName,
+					previous.Name,
+				),
+			).withRegion(regionName)
+		}
+
+		order := transportRegion.order
+		isTransport := order != nil && order.transports(move.Faction)
+		isOtherFactionShip := !transportRegion.empty() &&
+			unitTypes[transportRegion.Unit.Type].Naval &&
+			transportRegion.Unit.Faction != move.Faction
+		if !isTransport && !(trustOtherFactions && isOtherFactionShip) {
+			return false, "", newInputError(
+				ErrorCodeInvalidTransportPath,
+				fmt.Errorf("no ship in '%s' transports %s units", regionName, move.Faction),
+			).withRegion(regionName)
+		}
+
+		transportAttacked = transportAttacked || transportRegion.attacked()
+		previous = transportRegion
+	}
+
+	neighbor, adjacent := previous.getNeighbor(move.Destination, move.ViaDangerZone)
+	if !adjacent {
+		return false, "", newInputError(
+			ErrorCodeInvalidTransportPath,
+			fmt.Errorf("transport path does not reach '%s'", move.Destination),
+		).withRegion(move.Destination)
+	}
+
+	return transportAttacked, neighbor.DangerZone, nil
 }
 
 // Stores status of a path of transport orders to destination.
@@ -90,10 +179,12 @@ type transportPath struct {
 func (board Board) recursivelyFindTransportPath(
 	region *Region,
 	destination RegionName,
+	faction PlayerFaction,
 	excludedRegions set.Set[RegionName],
 ) (canTransport bool, transportAttacked bool, dangerZone DangerZone) {
 	transportingNeighbors, newExcludedRegions := region.getTransportingNeighbors(
 		board,
+		faction,
 		excludedRegions,
 	)
 
@@ -111,6 +202,7 @@ func (board Board) recursivelyFindTransportPath(
 		nextCanTransport, nextTransportAttacked, nextDangerZone := board.recursivelyFindTransportPath(
 			transportRegion,
 			destination,
+			faction,
 			newExcludedRegions,
 		)
 
@@ -147,8 +239,10 @@ func (board Board) recursivelyFindTransportPath(
 	return canTransport, bestPath.attacked, bestPath.dangerZone
 }
 
+// Returns the region's neighbors with ships that transport units of the given faction.
 func (region *Region) getTransportingNeighbors(
 	board Board,
+	faction PlayerFaction,
 	excludedRegions set.Set[RegionName],
 ) (transports []Neighbor, newExcludedRegions set.Set[RegionName]) {
 	newExcludedRegions = excludedRegions.Copy()
@@ -162,8 +256,7 @@ func (region *Region) getTransportingNeighbors(
 
 		if excludedRegions.Contains(neighbor.Name) ||
 			neighborRegion.order == nil ||
-			neighborRegion.order.Type != OrderTransport ||
-			neighborRegion.Unit.Faction != region.Unit.Faction {
+			!neighborRegion.order.transports(faction) {
 			continue
 		}
 
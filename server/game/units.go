@@ -1,6 +1,8 @@
 package game
 
 import (
+	"strconv"
+
 	"hermannm.dev/enumnames"
 )
 
@@ -10,6 +12,9 @@ type Unit struct {
 	Faction PlayerFaction
 }
 
+// Identifies the type of a unit. The built-in types are listed below, and board configs may define
+// additional types with other numbers. How each type moves and fights is given by the board's
+// [UnitTypes].
 type UnitType uint8
 
 const (
@@ -37,23 +42,128 @@ var unitNames = enumnames.NewMap(
 )
 
 func (unitType UnitType) String() string {
-	return unitNames.GetNameOrFallback(unitType, "INVALID")
+	if name, ok := unitNames.GetName(unitType); ok {
+		return name
+	}
+	return "UnitType" + strconv.Itoa(int(unitType))
+}
+
+// Returns the built-in unit types (used for generating protocol schemas). Unlike the Values methods
+// of other enums, these are only examples: board configs may define other unit types, which are
+// listed in [BoardInfo.UnitTypes].
+func (UnitType) ExampleValues() []UnitType {
+	return unitNames.Keys()
 }
 
-func (unitType UnitType) isValid() bool {
-	return unitNames.ContainsKey(unitType)
+// Properties of a unit type, defining how units of that type move and fight.
+type UnitTypeProperties struct {
+	Name string
+
+	// Whether the unit moves on water (seas and coastal regions) instead of land. Naval units can
+	// transport land units, but cannot besiege castles.
+	Naval bool
+
+	// The number of regions the unit can move in one move order: 1, or 2 for units that can give a
+	// second destination in their move orders.
+	MoveRange int
+
+	// Added to the unit's battle result when it attacks.
+	AttackModifier int
+
+	// Added to the unit's battle result when it defends its region.
+	DefenseModifier int
+
+	// Added to AttackModifier when the unit attacks a castle region.
+	CastleAttackModifier int
+
+	// Whether the unit takes control of uncontrolled castles by moving into them, instead of having
+	// to besiege them.
+	InstantCastleCapture bool
+
+	// The cost of building the unit in winter, in games with the economy rule (see [Rules]).
+	BuildCost int
 }
 
-func (unitType UnitType) battleModifier(
+// The unit types that can be used on a board.
+type UnitTypes map[UnitType]UnitTypeProperties
+
+// Returns the built-in unit types, which are available on every board unless overridden by the
+// board config.
+func defaultUnitTypes() UnitTypes {
+	return UnitTypes{
+		UnitFootman: {
+			Name:                 UnitFootman.String(),
+			Naval:                false,
+			MoveRange:            1,
+			AttackModifier:       1,
+			DefenseModifier:      1,
+			CastleAttackModifier: 0,
+			InstantCastleCapture: false,
+			BuildCost:            1,
+		},
+		UnitKnight: {
+			Name:                 UnitKnight.String(),
+			Naval:                false,
+			MoveRange:            2,
+			AttackModifier:       0,
+			DefenseModifier:      0,
+			CastleAttackModifier: 0,
+			InstantCastleCapture: false,
+			BuildCost:            2,
+		},
+		UnitShip: {
+			Name:                 UnitShip.String(),
+			Naval:                true,
+			MoveRange:            1,
+			AttackModifier:       0,
+			DefenseModifier:      0,
+			CastleAttackModifier: 0,
+			InstantCastleCapture: false,
+			BuildCost:            2,
+		},
+		UnitCatapult: {
+			Name:                 UnitCatapult.String(),
+			Naval:                false,
+			MoveRange:            1,
+			AttackModifier:       0,
+			DefenseModifier:      0,
+			CastleAttackModifier: 1,
+			InstantCastleCapture: true,
+			BuildCost:            2,
+		},
+	}
+}
+
+func (unitTypes UnitTypes) contains(unitType UnitType) bool {
+	_, ok := unitTypes[unitType]
+	return ok
+}
+
+// Returns the modifier for a unit of the given type attacking a region, if any.
+func (unitTypes UnitTypes) attackModifier(
+	unitType UnitType,
 	isAttackOnCastle bool,
 ) (modifier Modifier, hasModifier bool) {
-	modifierValue := 0
-	if unitType == UnitFootman || (unitType == UnitCatapult && isAttackOnCastle) {
-		modifierValue = 1
+	properties := unitTypes[unitType]
+
+	modifierValue := properties.AttackModifier
+	if isAttackOnCastle {
+		modifierValue += properties.CastleAttackModifier
 	}
 
-	if modifierValue != 0 {
-		return newModifier(ModifierUnit, modifierValue), true
+	return unitModifier(modifierValue)
+}
+
+// Returns the modifier for a unit of the given type defending its region, if any.
+func (unitTypes UnitTypes) defenseModifier(
+	unitType UnitType,
+) (modifier Modifier, hasModifier bool) {
+	return unitModifier(unitTypes[unitType].DefenseModifier)
+}
+
+func unitModifier(value int) (modifier Modifier, hasModifier bool) {
+	if value != 0 {
+		return newModifier(ModifierUnit, value), true
 	} else {
 		return Modifier{}, false
 	}
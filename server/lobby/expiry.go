@@ -0,0 +1,89 @@
+package lobby
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts after which abandoned lobbies are closed. A timeout of 0 disables that check.
+type LobbyExpiry struct {
+	// How long a lobby can stay without any connected players.
+	EmptyLobbyTimeout time.Duration
+
+	// How long a lobby can stay open without starting its game.
+	UnstartedLobbyTimeout time.Duration
+
+	// How long a started game can go without receiving any messages from players.
+	StalledGameTimeout time.Duration
+}
+
+// How often the registry checks for expired lobbies.
+const expiryCheckInterval = 1 * time.Minute
+
+// Periodically closes lobbies that have expired according to the registry's [LobbyExpiry]
+// config. Runs until the given context is canceled.
+func (registry *LobbyRegistry) CloseExpiredLobbies(ctx context.Context) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			registry.closeExpiredLobbiesAt(now)
+		}
+	}
+}
+
+func (registry *LobbyRegistry) closeExpiredLobbiesAt(now time.Time) {
+	registry.lock.RLock()
+	lobbies := make([]*Lobby, len(registry.lobbies))
+	copy(lobbies, registry.lobbies)
+	registry.lock.RUnlock()
+
+	// Closes lobbies after releasing the registry lock, since Lobby.Close removes the lobby from
+	// the registry
+	for _, lobby := range lobbies {
+		if expired, reason := lobby.expired(registry.expiry, now); expired {
+			lobby.Close(reason)
+		}
+	}
+}
+
+func (lobby *Lobby) expired(expiry LobbyExpiry, now time.Time) (expired bool, reason string) {
+	if lobby.neverExpires {
+		return false, ""
+	}
+
+	lobby.lock.RLock()
+	defer lobby.lock.RUnlock()
+
+	if expiry.EmptyLobbyTimeout != 0 &&
+		len(lobby.players) == 0 &&
+		now.Sub(lobby.emptySince) > expiry.EmptyLobbyTimeout {
+		return true, "lobby has been empty for more than " + expiry.EmptyLobbyTimeout.String()
+	}
+
+	if expiry.UnstartedLobbyTimeout != 0 &&
+		!lobby.gameStarted &&
+		now.Sub(lobby.createdAt) > expiry.UnstartedLobbyTimeout {
+		return true, "game not started within " + expiry.UnstartedLobbyTimeout.String()
+	}
+
+	if expiry.StalledGameTimeout != 0 &&
+		lobby.gameStarted &&
+		now.Sub(lobby.lastActivity) > expiry.StalledGameTimeout {
+		return true, "no player activity in game for " + expiry.StalledGameTimeout.String()
+	}
+
+	return false, ""
+}
+
+// Marks that the lobby has received a message from a player, to keep it from expiring.
+func (lobby *Lobby) registerActivity() {
+	lobby.lock.Lock()
+	defer lobby.lock.Unlock()
+
+	lobby.lastActivity = time.Now()
+}
@@ -0,0 +1,63 @@
+package lobby
+
+import (
+	"slices"
+
+	"hermannm.dev/set"
+
+	"hermannm.dev/casus-belli/server/game"
+)
+
+// Keeps track of the current round's orders in lobbies with hidden orders (see [LobbyOptions]), so
+// that the orders involved in battles can be revealed once the round is resolved.
+//
+// Only accessed from the game's goroutine (through the lobby's [game.Messenger] methods), so it
+// needs no lock.
+type hiddenOrderState struct {
+	orders        map[game.PlayerFaction][]*game.Order
+	battleRegions set.ArraySet[game.RegionName]
+}
+
+// Starts tracking the given orders for a new round.
+func (state *hiddenOrderState) newRound(orders map[game.PlayerFaction][]*game.Order) {
+	state.orders = orders
+	state.battleRegions.Clear()
+}
+
+func (state *hiddenOrderState) addBattle(battle game.Battle) {
+	for _, region := range battle.RegionNames() {
+		state.battleRegions.Add(region)
+	}
+}
+
+// Returns whether the given order should be shown to the given faction: either because it is their
+// own order, or because it touched a region where a battle took place this round.
+func (state *hiddenOrderState) isRevealed(order *game.Order, to game.PlayerFaction) bool {
+	return order.Faction == to ||
+		state.battleRegions.Contains(order.Origin) ||
+		(order.Destination != "" && state.battleRegions.Contains(order.Destination))
+}
+
+// Returns the orders of the current round that are revealed to the given faction.
+func (state *hiddenOrderState) revealedOrders(
+	to game.PlayerFaction,
+) map[game.PlayerFaction][]*game.Order {
+	revealed := make(map[game.PlayerFaction][]*game.Order, len(state.orders))
+	for faction, orders := range state.orders {
+		revealed[faction] = slices.DeleteFunc(slices.Clone(orders), func(order *game.Order) bool {
+			return !state.isRevealed(order, to)
+		})
+	}
+	return revealed
+}
+
+// Returns the given resolution events, without the ones concerning orders that are not revealed
+// to the given faction.
+func (state *hiddenOrderState) filterResolutionEvents(
+	events []game.ResolutionEvent,
+	to game.PlayerFaction,
+) []game.ResolutionEvent {
+	return slices.DeleteFunc(slices.Clone(events), func(event game.ResolutionEvent) bool {
+		return event.Order != nil && !state.isRevealed(event.Order, to)
+	})
+}
@@ -1,10 +1,13 @@
 package lobby
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"slices"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"hermannm.dev/condqueue"
@@ -16,13 +19,67 @@ import (
 // A collection of players for a game.
 type Lobby struct {
 	name             string
+	options          LobbyOptions
 	players          []*Player // Must hold lock to access safely.
 	game             *game.Game
-	gameStarted      bool // Must hold lock to access safely.
+	gameStarted      bool                    // Must hold lock to access safely.
+	cancelGame       context.CancelCauseFunc // Non-nil while game runs. Must hold lock to access.
+	gameDone         chan struct{}           // Closed when game has stopped and lobby is closed.
 	gameMessageQueue *condqueue.CondQueue[ReceivedMessage]
 	registry         *LobbyRegistry
 	lock             sync.RWMutex
 	log              log.Logger
+
+	// Orders of the current round, for revealing only orders involved in battles. Only used if
+	// the lobby has hidden orders (see [LobbyOptions]).
+	hiddenOrders hiddenOrderState
+
+	// Fields for closing abandoned lobbies (see [LobbyExpiry]). Must hold lock to access safely,
+	// except for neverExpires.
+	neverExpires bool
+	createdAt    time.Time
+	emptySince   time.Time // Zero value when the lobby has players.
+	lastActivity time.Time
+	closed       bool
+}
+
+// Options for creating a lobby.
+type LobbyOptions struct {
+	// If not blank: players must provide this password in order to join the lobby.
+	Password string
+
+	// Whether to hide the lobby from the public lobby list. Players can still join an unlisted
+	// lobby if they know its name.
+	Unlisted bool
+
+	// Whether players should only see the parts of the board near their own units and regions (see
+	// [game.Visibility]), instead of the whole board and everyone's orders.
+	FogOfWar bool
+
+	// Whether players should only see their own orders when orders are submitted. Other players'
+	// orders are revealed after the round is resolved, and only if they touched a region where a
+	// battle took place.
+	HiddenOrders bool
+
+	// Optional rule variants for the lobby's game.
+	Rules game.Rules
+}
+
+// Checks the given password against the lobby's password, if it has one.
+func (lobby *Lobby) CheckPassword(password string) error {
+	if lobby.options.Password == "" {
+		return nil
+	}
+
+	if password == "" {
+		return fmt.Errorf("lobby '%s' requires a password", lobby.name)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(password), []byte(lobby.options.Password)) != 1 {
+		return fmt.Errorf("wrong password for lobby '%s'", lobby.name)
+	}
+
+	return nil
 }
 
 func (lobby *Lobby) getPlayer(faction game.PlayerFaction) (player *Player, foundPlayer bool) {
@@ -46,6 +103,10 @@ func (lobby *Lobby) AddPlayer(username string, socket *websocket.Conn) (*Player,
 		return nil, errors.New("username cannot be blank")
 	}
 
+	if lobby.registry.ShuttingDown() {
+		return nil, errServerShutdown
+	}
+
 	if lobby.isUsernameTaken(username) {
 		return nil, fmt.Errorf("username '%s' already taken", username)
 	}
@@ -57,6 +118,8 @@ func (lobby *Lobby) AddPlayer(username string, socket *websocket.Conn) (*Player,
 
 	lobby.log.Infof(nil, "Player '%s' joined", username)
 	lobby.players = append(lobby.players, player)
+	lobby.emptySince = time.Time{}
+	lobby.lastActivity = time.Now()
 	go player.readMessagesUntilSocketCloses(lobby)
 
 	return player, nil
@@ -69,6 +132,9 @@ func (lobby *Lobby) RemovePlayer(username Username) {
 	for i, player := range lobby.players {
 		if player.username == username {
 			lobby.players = slices.Delete(lobby.players, i, i+1)
+			if len(lobby.players) == 0 {
+				lobby.emptySince = time.Now()
+			}
 			return
 		}
 	}
@@ -87,23 +153,37 @@ func (lobby *Lobby) isUsernameTaken(username string) bool {
 	return false
 }
 
-func (lobby *Lobby) Close() {
+// Disconnects all players and removes the lobby from the registry. The given reason is logged.
+// Does nothing if the lobby is already closed.
+//
+// If the lobby's game is running, it is aborted with the given reason, and the lobby is closed once
+// the game has informed players and returned.
+func (lobby *Lobby) Close(reason string) {
 	lobby.lock.Lock()
 	defer lobby.lock.Unlock()
 
-	for _, player := range lobby.players {
-		player.lock.Lock()
+	if lobby.closed {
+		return
+	}
 
-		if err := player.socket.Close(); err != nil {
-			player.log.Error(nil, err, "Failed to close socket connection")
-		}
+	if lobby.cancelGame != nil {
+		lobby.cancelGame(errors.New(reason))
+		return
+	}
+
+	lobby.closed = true
 
-		player.lock.Unlock()
+	closeCode := websocket.CloseNormalClosure
+	if lobby.registry.ShuttingDown() {
+		closeCode = websocket.CloseGoingAway
+	}
+	for _, player := range lobby.players {
+		player.closeConnection(closeCode, reason)
 	}
 
 	lobby.registry.removeLobby(lobby.name)
 
-	lobby.log.Info(nil, "Lobby closed")
+	lobby.log.Info(nil, "Lobby closed", "reason", reason)
 }
 
 func (lobby *Lobby) ClearMessages() {
@@ -129,10 +209,28 @@ func (lobby *Lobby) startGame() error {
 
 	lobby.log.Info(nil, "Starting game")
 	lobby.gameStarted = true
+	lobby.lastActivity = time.Now()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	lobby.cancelGame = cancel
 
 	go func() {
-		lobby.game.Run() // Runs until game is finished
-		lobby.Close()
+		err := lobby.game.Run(ctx) // Runs until game is finished or aborted
+
+		lobby.lock.Lock()
+		lobby.cancelGame(nil)
+		lobby.cancelGame = nil
+		lobby.lock.Unlock()
+
+		if err != nil {
+			lobby.log.Error(nil, err, "")
+			lobby.SendGameAborted(err)
+			lobby.Close(err.Error())
+		} else {
+			lobby.Close("game finished")
+		}
+
+		close(lobby.gameDone)
 	}()
 
 	return nil
@@ -5,21 +5,82 @@ import (
 	"fmt"
 	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"hermannm.dev/condqueue"
 	"hermannm.dev/devlog/log"
 	"hermannm.dev/wrap"
 
 	"hermannm.dev/casus-belli/server/game"
+	"hermannm.dev/casus-belli/server/metrics"
 )
 
 type LobbyRegistry struct {
-	lobbies []*Lobby
-	lock    sync.RWMutex
+	lobbies      []*Lobby
+	expiry       LobbyExpiry
+	shuttingDown atomic.Bool
+	lock         sync.RWMutex
 }
 
-func NewLobbyRegistry() *LobbyRegistry {
-	return &LobbyRegistry{lobbies: nil, lock: sync.RWMutex{}}
+func NewLobbyRegistry(expiry LobbyExpiry) *LobbyRegistry {
+	registry := &LobbyRegistry{
+		lobbies:      nil,
+		expiry:       expiry,
+		shuttingDown: atomic.Bool{},
+		lock:         sync.RWMutex{},
+	}
+	registry.registerMetrics()
+	return registry
+}
+
+func (registry *LobbyRegistry) registerMetrics() {
+	metrics.Default.NewGaugeFunc(
+		"casus_belli_lobbies_open",
+		"Number of open lobbies.",
+		func() float64 {
+			registry.lock.RLock()
+			defer registry.lock.RUnlock()
+			return float64(len(registry.lobbies))
+		},
+	)
+	metrics.Default.NewGaugeFunc(
+		"casus_belli_players_connected",
+		"Number of players connected to a lobby.",
+		func() float64 {
+			return float64(registry.countLobbies(func(lobby *Lobby) int { return len(lobby.players) }))
+		},
+	)
+	metrics.Default.NewGaugeFunc(
+		"casus_belli_games_in_progress",
+		"Number of lobbies with a running game.",
+		func() float64 {
+			return float64(
+				registry.countLobbies(
+					func(lobby *Lobby) int {
+						if lobby.cancelGame != nil {
+							return 1
+						}
+						return 0
+					},
+				),
+			)
+		},
+	)
+}
+
+// Sums the given count function over all lobbies, calling it while holding each lobby's lock.
+func (registry *LobbyRegistry) countLobbies(count func(lobby *Lobby) int) int {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	total := 0
+	for _, lobby := range registry.lobbies {
+		lobby.lock.RLock()
+		total += count(lobby)
+		lobby.lock.RUnlock()
+	}
+	return total
 }
 
 func (registry *LobbyRegistry) GetLobby(name string) (lobby *Lobby, lobbyFound bool) {
@@ -40,20 +101,35 @@ func (registry *LobbyRegistry) CreateLobby(
 	boardID string,
 	onlyLobbyOnServer bool,
 	customPlayerFactions []game.PlayerFaction,
+	options LobbyOptions,
 ) error {
 	if lobbyName == "" {
 		return errors.New("lobby name cannot be blank")
 	}
 
+	if registry.ShuttingDown() {
+		return errServerShutdown
+	}
+
+	now := time.Now()
 	lobby := &Lobby{
 		name:             lobbyName,
+		options:          options,
 		players:          nil,
 		game:             nil,
 		gameStarted:      false,
+		cancelGame:       nil,
+		gameDone:         make(chan struct{}),
 		gameMessageQueue: condqueue.New[ReceivedMessage](),
 		registry:         registry,
 		lock:             sync.RWMutex{},
 		log:              log.Logger{},
+		hiddenOrders:     hiddenOrderState{}, //nolint:exhaustruct
+		neverExpires:     onlyLobbyOnServer,
+		createdAt:        now,
+		emptySince:       now,
+		lastActivity:     now,
+		closed:           false,
 	}
 
 	logger := log.Default()
@@ -71,7 +147,7 @@ func (registry *LobbyRegistry) CreateLobby(
 		boardInfo.PlayerFactions = customPlayerFactions
 	}
 
-	game := game.New(board, boardInfo, lobby, lobby.log, nil)
+	game := game.New(board, boardInfo, options.Rules, lobby, lobby.log, nil)
 	lobby.game = game
 	lobby.players = make([]*Player, 0, len(game.PlayerFactions))
 
@@ -104,6 +180,18 @@ type LobbyInfo struct {
 	Name        string
 	PlayerCount int
 	BoardInfo   game.BoardInfo
+
+	// Whether players must provide a password to join the lobby.
+	HasPassword bool
+
+	// Whether the lobby uses fog of war (see [LobbyOptions]).
+	FogOfWar bool
+
+	// Whether the lobby uses hidden orders (see [LobbyOptions]).
+	HiddenOrders bool
+
+	// The rule variants used by the lobby's game.
+	Rules game.Rules
 }
 
 func (registry *LobbyRegistry) ListLobbies() []LobbyInfo {
@@ -112,13 +200,25 @@ func (registry *LobbyRegistry) ListLobbies() []LobbyInfo {
 
 	lobbyList := make([]LobbyInfo, 0, len(registry.lobbies))
 	for _, lobby := range registry.lobbies {
+		if lobby.options.Unlisted {
+			continue
+		}
+
 		lobby.lock.RLock()
 		playerCount := len(lobby.players)
 		lobby.lock.RUnlock()
 
 		lobbyList = append(
 			lobbyList,
-			LobbyInfo{Name: lobby.name, PlayerCount: playerCount, BoardInfo: lobby.game.BoardInfo},
+			LobbyInfo{
+				Name:         lobby.name,
+				PlayerCount:  playerCount,
+				BoardInfo:    lobby.game.BoardInfo,
+				HasPassword:  lobby.options.Password != "",
+				FogOfWar:     lobby.options.FogOfWar,
+				HiddenOrders: lobby.options.HiddenOrders,
+				Rules:        lobby.options.Rules,
+			},
 		)
 	}
 
@@ -41,6 +41,8 @@ func (player *Player) readMessage(lobby *Lobby) (socketClosed bool, err error) {
 		}
 	}
 
+	lobby.registerActivity()
+
 	var message struct {
 		Tag  MessageTag      `json:"Tag"`
 		Data json.RawMessage `json:"Data"`
@@ -115,6 +117,64 @@ func (player *Player) handleGameMessage(
 			return wrap.Error(err, "failed to parse message")
 		}
 		messageData = message
+	case MessageTagRetreat:
+		var message RetreatMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+		messageData = message
+	case MessageTagAddDraftOrder:
+		var message AddDraftOrderMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+		if message.Order == nil {
+			return errors.New("draft order cannot be null")
+		}
+		messageData = message
+	case MessageTagEditDraftOrder:
+		var message EditDraftOrderMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+		if message.Order == nil {
+			return errors.New("draft order cannot be null")
+		}
+		messageData = message
+	case MessageTagRemoveDraftOrder:
+		var message RemoveDraftOrderMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+		messageData = message
+	case MessageTagRetractOrders:
+		messageData = RetractOrdersMessage{}
+	case MessageTagLegalOrdersRequest:
+		var message LegalOrdersRequestMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+		messageData = message
+	case MessageTagBoardSnapshotRequest:
+		// Answered directly, as the game keeps a snapshot that is safe to read at any time
+		player.SendBoardSnapshot(lobby)
+		return nil
+	case MessageTagOrderDraftRequest:
+		// Answered directly, like board snapshots
+		if hasDraft := player.SendOrderDraft(lobby); !hasDraft {
+			return errors.New("game is not waiting for orders from the player")
+		}
+		return nil
+	case MessageTagSelectFaction:
+		var message SelectFactionMessage
+		if err := json.Unmarshal(rawMessage, &message); err != nil {
+			return wrap.Error(err, "failed to parse message")
+		}
+
+		if err := player.rejoinGame(message.Faction, lobby); err != nil {
+			return wrap.Error(err, "failed to rejoin game")
+		}
+		return nil
 	default:
 		return fmt.Errorf("invalid game message tag '%s'", messageTag)
 	}
@@ -129,33 +189,69 @@ func (player *Player) handleGameMessage(
 	return nil
 }
 
-// Checks that none of the returned orders are nil.
-func (lobby *Lobby) AwaitOrders(
+// Waits for the given player to either submit or retract orders, change their order draft, or ask
+// for legal orders.
+// Checks that none of the submitted orders are nil (draft orders are checked in
+// [Player.handleGameMessage]).
+func (lobby *Lobby) AwaitOrderInput(
 	ctx context.Context,
 	from game.PlayerFaction,
-) ([]*game.Order, error) {
+) (game.OrderInput, error) {
+	input := game.OrderInput{Type: 0, Orders: nil, Order: nil, DraftIndex: 0, Region: ""}
+
 	message, err := lobby.gameMessageQueue.AwaitMatchingItem(
 		ctx,
 		func(message ReceivedMessage) bool {
-			return message.ReceivedFrom == from && message.Tag == MessageTagSubmitOrders
+			if message.ReceivedFrom != from {
+				return false
+			}
+
+			switch message.Tag {
+			case MessageTagSubmitOrders,
+				MessageTagRetractOrders,
+				MessageTagAddDraftOrder,
+				MessageTagEditDraftOrder,
+				MessageTagRemoveDraftOrder,
+				MessageTagLegalOrdersRequest:
+				return true
+			default:
+				return false
+			}
 		},
 	)
 	if err != nil {
-		return nil, err
-	}
-
-	messageData, ok := message.Data.(SubmitOrdersMessage)
-	if !ok {
-		return nil, errors.New("failed to cast received message to SubmitOrdersMessage")
+		return input, err
 	}
 
-	for _, order := range messageData.Orders {
-		if order == nil {
-			return nil, errors.New("received nil order in SubmitOrdersMessage")
+	switch messageData := message.Data.(type) {
+	case SubmitOrdersMessage:
+		for _, order := range messageData.Orders {
+			if order == nil {
+				return input, errors.New("received nil order in SubmitOrdersMessage")
+			}
 		}
+		input.Type = game.OrderInputSubmit
+		input.Orders = messageData.Orders
+	case AddDraftOrderMessage:
+		input.Type = game.OrderInputAddDraft
+		input.Order = messageData.Order
+	case EditDraftOrderMessage:
+		input.Type = game.OrderInputEditDraft
+		input.Order = messageData.Order
+		input.DraftIndex = messageData.Index
+	case RemoveDraftOrderMessage:
+		input.Type = game.OrderInputRemoveDraft
+		input.DraftIndex = messageData.Index
+	case RetractOrdersMessage:
+		input.Type = game.OrderInputRetract
+	case LegalOrdersRequestMessage:
+		input.Type = game.OrderInputLegalOrdersRequest
+		input.Region = messageData.Region
+	default:
+		return input, fmt.Errorf("failed to cast received message of type '%s'", message.Tag)
 	}
 
-	return messageData.Orders, nil
+	return input, nil
 }
 
 func (lobby *Lobby) AwaitSupport(
@@ -190,6 +286,38 @@ func (lobby *Lobby) AwaitSupport(
 	return messageData.SupportedFaction, nil
 }
 
+func (lobby *Lobby) AwaitRetreat(
+	ctx context.Context,
+	from game.PlayerFaction,
+	retreatingFrom game.RegionName,
+) (destination game.RegionName, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	message, err := lobby.gameMessageQueue.AwaitMatchingItem(
+		ctx,
+		func(message ReceivedMessage) bool {
+			if message.ReceivedFrom != from || message.Tag != MessageTagRetreat {
+				return false
+			}
+
+			messageData, ok := message.Data.(RetreatMessage)
+			if !ok {
+				cancel(errors.New("failed to cast received message to RetreatMessage"))
+				return false
+			}
+
+			return messageData.RetreatingFrom == retreatingFrom
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	//nolint:errcheck // Already checked inside AwaitMatchingItem
+	messageData := message.Data.(RetreatMessage)
+	return messageData.Destination, nil
+}
+
 func (lobby *Lobby) AwaitDiceRoll(ctx context.Context, from game.PlayerFaction) error {
 	_, err := lobby.gameMessageQueue.AwaitMatchingItem(
 		ctx, func(message ReceivedMessage) bool {
@@ -2,7 +2,9 @@ package lobby
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gorilla/websocket"
 
@@ -65,6 +67,49 @@ func (lobby *Lobby) sendMessageToAll(message Message) {
 	}
 }
 
+// Sends a message to each player, built for their faction if the lobby uses fog of war or hidden
+// orders (see [LobbyOptions]). With fog of war, the message is built from what the player's faction
+// can see; otherwise, the visibility is nil (which sees the whole board). If the lobby uses neither
+// option, the message is built once for a blank faction, and sent to all players. The newMessage
+// function returns false if the message should not be sent to the player.
+//
+// Must only be called from the game's goroutine, since it reads the game board.
+func (lobby *Lobby) sendMessagePerPlayer(
+	newMessage func(
+		recipient game.PlayerFaction,
+		visibility *game.Visibility,
+	) (message Message, send bool),
+) {
+	if !lobby.options.FogOfWar && !lobby.options.HiddenOrders {
+		if message, send := newMessage("", nil); send {
+			lobby.sendMessageToAll(message)
+		}
+		return
+	}
+
+	lobby.lock.RLock()
+	defer lobby.lock.RUnlock()
+
+	for _, player := range lobby.players {
+		player.lock.RLock()
+		faction := player.gameFaction
+		player.lock.RUnlock()
+
+		if faction == "" {
+			continue
+		}
+
+		var visibility *game.Visibility
+		if lobby.options.FogOfWar {
+			visibility = lobby.game.Visibility(faction)
+		}
+
+		if message, send := newMessage(faction, visibility); send {
+			player.sendMessage(message)
+		}
+	}
+}
+
 func (player *Player) SendLobbyJoinedMessage(lobby *Lobby) {
 	lobby.lock.RLock()
 	defer lobby.lock.RUnlock()
@@ -92,6 +137,7 @@ func (player *Player) SendLobbyJoinedMessage(lobby *Lobby) {
 			Data: LobbyJoinedMessage{
 				SelectableFactions: lobby.game.PlayerFactions,
 				PlayerStatuses:     statuses,
+				ProtocolVersion:    ProtocolVersion,
 			},
 		},
 	)
@@ -113,11 +159,52 @@ func (lobby *Lobby) SendPlayerStatusMessage(player *Player) {
 	)
 }
 
+// Creates an error message from the given error, including the details of the [game.InputError] in
+// the error chain, if any. If the error chain contains [game.OrderValidationErrors], each of the
+// errors is included with its own details.
+func newErrorMessage(err error) ErrorMessage {
+	message := ErrorMessage{Error: err.Error(), Details: nil, OrderErrors: nil}
+
+	var validationErrs game.OrderValidationErrors
+	if errors.As(err, &validationErrs) {
+		message.OrderErrors = newOrderErrors(validationErrs)
+		return message
+	}
+
+	var inputErr *game.InputError
+	if errors.As(err, &inputErr) {
+		details := inputErr.Details
+		message.Details = &details
+	}
+
+	return message
+}
+
+func newOrderErrors(errs game.OrderValidationErrors) []OrderError {
+	orderErrors := make([]OrderError, 0, len(errs))
+	for _, err := range errs {
+		details := game.ErrorDetails{
+			Code:       game.ErrorCodeUnknown,
+			OrderIndex: nil,
+			Region:     "",
+			Expected:   "",
+			Actual:     "",
+		}
+		var inputErr *game.InputError
+		if errors.As(err, &inputErr) {
+			details = inputErr.Details
+		}
+
+		orderErrors = append(orderErrors, OrderError{Error: err.Error(), Details: details})
+	}
+	return orderErrors
+}
+
 func (player *Player) SendError(err error) {
 	player.sendMessage(
 		Message{
 			Tag:  MessageTagError,
-			Data: ErrorMessage{Error: err.Error()},
+			Data: newErrorMessage(err),
 		},
 	)
 }
@@ -126,61 +213,267 @@ func (lobby *Lobby) SendError(to game.PlayerFaction, err error) {
 	lobby.sendMessage(
 		to, Message{
 			Tag:  MessageTagError,
-			Data: ErrorMessage{Error: err.Error()},
+			Data: newErrorMessage(err),
 		},
 	)
 }
 
 func (lobby *Lobby) SendGameStarted(board game.Board) {
-	lobby.sendMessageToAll(
-		Message{
-			Tag:  MessageTagGameStarted,
-			Data: GameStartedMessage{Board: board},
+	lobby.sendMessagePerPlayer(
+		func(_ game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			return Message{
+				Tag: MessageTagGameStarted,
+				Data: GameStartedMessage{
+					Board:     visibility.FilterBoard(board),
+					UnitTypes: lobby.game.UnitTypes,
+				},
+			}, true
 		},
 	)
 }
 
-func (lobby *Lobby) SendOrderRequest(to game.PlayerFaction, season game.Season) (succeeded bool) {
+func (lobby *Lobby) SendOrderRequest(
+	to game.PlayerFaction,
+	season game.Season,
+	draft []*game.Order,
+	buildPlan *game.BuildPlan,
+) (succeeded bool) {
 	return lobby.sendMessage(
 		to, Message{
 			Tag:  MessageTagOrderRequest,
-			Data: OrderRequestMessage{Season: season},
+			Data: OrderRequestMessage{Season: season, Draft: draft, BuildPlan: buildPlan},
 		},
 	)
 }
 
+func (lobby *Lobby) SendOrderDraft(
+	to game.PlayerFaction,
+	draft []*game.Order,
+	errs game.OrderValidationErrors,
+) {
+	lobby.sendMessage(
+		to, Message{
+			Tag:  MessageTagOrderDraft,
+			Data: OrderDraftMessage{Draft: draft, Errors: newOrderErrors(errs)},
+		},
+	)
+}
+
+func (lobby *Lobby) SendLegalOrders(
+	to game.PlayerFaction,
+	region game.RegionName,
+	orders []*game.Order,
+) {
+	lobby.sendMessage(
+		to, Message{
+			Tag:  MessageTagLegalOrders,
+			Data: LegalOrdersMessage{Region: region, Orders: orders},
+		},
+	)
+}
+
+// In lobbies with hidden orders, players only receive their own orders here. Orders involved in
+// battles are revealed once the round is resolved (see [Lobby.SendRoundReport]).
 func (lobby *Lobby) SendOrdersReceived(orders map[game.PlayerFaction][]*game.Order) {
+	if lobby.options.HiddenOrders {
+		lobby.hiddenOrders.newRound(orders)
+	}
+
+	lobby.sendMessagePerPlayer(
+		func(recipient game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			shownOrders := orders
+			if lobby.options.HiddenOrders {
+				shownOrders = lobby.hiddenOrders.revealedOrders(recipient)
+			}
+
+			visibleOrders := make(map[game.PlayerFaction][]*game.Order, len(shownOrders))
+			for faction, factionOrders := range shownOrders {
+				visibleOrders[faction] = visibility.FilterOrders(factionOrders)
+			}
+
+			return Message{
+				Tag:  MessageTagOrdersReceived,
+				Data: OrdersReceivedMessage{OrdersByFaction: visibleOrders},
+			}, true
+		},
+	)
+}
+
+func (lobby *Lobby) SendOrdersConfirmation(factionThatSubmittedOrders game.PlayerFaction) {
 	lobby.sendMessageToAll(
 		Message{
-			Tag:  MessageTagOrdersReceived,
-			Data: OrdersReceivedMessage{OrdersByFaction: orders},
+			Tag: MessageTagOrdersConfirmation,
+			Data: OrdersConfirmationMessage{
+				FactionThatSubmittedOrders: factionThatSubmittedOrders,
+				Retracted:                  false,
+			},
 		},
 	)
 }
 
-func (lobby *Lobby) SendOrdersConfirmation(factionThatSubmittedOrders game.PlayerFaction) {
+func (lobby *Lobby) SendOrdersRetracted(factionThatRetractedOrders game.PlayerFaction) {
 	lobby.sendMessageToAll(
 		Message{
-			Tag:  MessageTagOrdersConfirmation,
-			Data: OrdersConfirmationMessage{FactionThatSubmittedOrders: factionThatSubmittedOrders},
+			Tag: MessageTagOrdersConfirmation,
+			Data: OrdersConfirmationMessage{
+				FactionThatSubmittedOrders: factionThatRetractedOrders,
+				Retracted:                  true,
+			},
 		},
 	)
 }
 
 func (lobby *Lobby) SendBattleAnnouncement(battle game.Battle) {
+	if lobby.options.HiddenOrders {
+		lobby.hiddenOrders.addBattle(battle)
+	}
+
+	lobby.sendMessagePerPlayer(
+		func(_ game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			return Message{
+				Tag:  MessageTagBattleAnnouncement,
+				Data: BattleAnnouncementMessage{Battle: battle},
+			}, visibility.CanSeeBattle(battle)
+		},
+	)
+}
+
+func (lobby *Lobby) SendBattleResults(battle game.Battle) {
+	lobby.sendMessagePerPlayer(
+		func(_ game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			return Message{
+				Tag:  MessageTagBattleResults,
+				Data: BattleResultsMessage{Battle: battle},
+			}, visibility.CanSeeBattle(battle)
+		},
+	)
+}
+
+func (lobby *Lobby) SendGameAborted(err error) {
 	lobby.sendMessageToAll(
 		Message{
-			Tag:  MessageTagBattleAnnouncement,
-			Data: BattleAnnouncementMessage{Battle: battle},
+			Tag:  MessageTagGameAborted,
+			Data: GameAbortedMessage{Reason: err.Error()},
 		},
 	)
 }
 
-func (lobby *Lobby) SendBattleResults(battle game.Battle) {
+func (lobby *Lobby) SendServerShutdown(gracePeriod time.Duration) {
 	lobby.sendMessageToAll(
 		Message{
-			Tag:  MessageTagBattleResults,
-			Data: BattleResultsMessage{Battle: battle},
+			Tag:  MessageTagServerShutdown,
+			Data: ServerShutdownMessage{GracePeriodSeconds: int(gracePeriod.Seconds())},
+		},
+	)
+}
+
+func (lobby *Lobby) SendBoardDiff(diff game.BoardDiff) {
+	// The snapshot is the board after the diff, since the game takes it before sending the diff
+	board, _ := lobby.game.BoardSnapshot()
+
+	lobby.sendMessagePerPlayer(
+		func(_ game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			visibleDiff := visibility.FilterBoardDiff(diff, board)
+			return Message{
+				Tag:  MessageTagBoardDiff,
+				Data: BoardDiffMessage{Diff: visibleDiff},
+			}, !visibleDiff.Empty()
+		},
+	)
+}
+
+func (player *Player) SendBoardSnapshot(lobby *Lobby) {
+	board, season := lobby.game.BoardSnapshot()
+	if lobby.options.FogOfWar {
+		player.lock.RLock()
+		faction := player.gameFaction
+		player.lock.RUnlock()
+
+		// Uses the snapshot to find visible regions, as the game board may be mid-resolution
+		board = board.VisibilityFor(faction).FilterBoard(board)
+	}
+
+	player.sendMessage(
+		Message{
+			Tag:  MessageTagBoardSnapshot,
+			Data: BoardSnapshotMessage{Board: board, Season: season},
+		},
+	)
+}
+
+// Sends the player's current order draft, if the game is waiting for their orders. Returns false if
+// it is not.
+func (player *Player) SendOrderDraft(lobby *Lobby) (hasDraft bool) {
+	player.lock.RLock()
+	faction := player.gameFaction
+	player.lock.RUnlock()
+
+	draft, errs, hasDraft := lobby.game.CurrentDraft(faction)
+	if !hasDraft {
+		return false
+	}
+
+	player.sendMessage(
+		Message{
+			Tag:  MessageTagOrderDraft,
+			Data: OrderDraftMessage{Draft: draft, Errors: newOrderErrors(errs)},
+		},
+	)
+	return true
+}
+
+// In lobbies with hidden orders, also reveals the orders that were involved in battles this round.
+func (lobby *Lobby) SendRoundReport(season game.Season, events []game.ResolutionEvent) {
+	if lobby.options.HiddenOrders {
+		lobby.sendMessagePerPlayer(
+			func(recipient game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+				revealedOrders := lobby.hiddenOrders.revealedOrders(recipient)
+				for faction, orders := range revealedOrders {
+					revealedOrders[faction] = visibility.FilterOrders(orders)
+				}
+
+				return Message{
+					Tag:  MessageTagOrdersRevealed,
+					Data: OrdersRevealedMessage{OrdersByFaction: revealedOrders},
+				}, true
+			},
+		)
+	}
+
+	lobby.sendMessagePerPlayer(
+		func(recipient game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			visibleEvents := visibility.FilterResolutionEvents(events)
+			if lobby.options.HiddenOrders {
+				visibleEvents = lobby.hiddenOrders.filterResolutionEvents(visibleEvents, recipient)
+			}
+
+			return Message{
+				Tag:  MessageTagRoundReport,
+				Data: RoundReportMessage{Season: season, Events: visibleEvents},
+			}, true
+		},
+	)
+}
+
+func (lobby *Lobby) SendStandings(season game.Season, standings []game.Standing) {
+	lobby.sendMessagePerPlayer(
+		func(_ game.PlayerFaction, visibility *game.Visibility) (Message, bool) {
+			return Message{
+				Tag: MessageTagStandings,
+				Data: StandingsMessage{
+					Season:    season,
+					Standings: visibility.FilterStandings(standings),
+				},
+			}, true
+		},
+	)
+}
+
+func (lobby *Lobby) SendRetreatRequest(to game.PlayerFaction, request game.RetreatRequest) {
+	lobby.sendMessage(
+		to, Message{
+			Tag:  MessageTagRetreatRequest,
+			Data: RetreatRequestMessage{Request: request},
 		},
 	)
 }
@@ -2,6 +2,7 @@ package lobby
 
 import (
 	"log/slog"
+	"reflect"
 
 	"hermannm.dev/enumnames"
 
@@ -29,13 +30,32 @@ type ReceivedMessage struct {
 
 // Message sent from server when an error occurs.
 type ErrorMessage struct {
+	// Human-readable description of the error.
 	Error string `json:"Error"`
+
+	// Set if the error was caused by invalid input from the player, e.g. invalid orders, so that
+	// the client can show a translated message and highlight the cause of the error.
+	Details *game.ErrorDetails `json:"Details,omitempty"`
+
+	// Set if the error was caused by the player submitting invalid orders. Lists every error found
+	// in the order set, so that the player can fix them all at once. The Error field then contains
+	// all the errors combined.
+	OrderErrors []OrderError `json:"OrderErrors,omitempty"`
+}
+
+// An error found when validating a player's submitted orders (see [ErrorMessage]).
+type OrderError struct {
+	Error   string            `json:"Error"`
+	Details game.ErrorDetails `json:"Details"`
 }
 
 // Message sent to a player when they join a lobby, to inform them about the game and other players.
 type LobbyJoinedMessage struct {
 	SelectableFactions []game.PlayerFaction  `json:"SelectableFactions"`
 	PlayerStatuses     []PlayerStatusMessage `json:"PlayerStatuses"`
+
+	// The protocol version used by the server (see [ProtocolVersion]).
+	ProtocolVersion int `json:"ProtocolVersion"`
 }
 
 // Message sent from server to all clients when a player's status changes.
@@ -44,7 +64,9 @@ type PlayerStatusMessage struct {
 	SelectedFaction game.PlayerFaction `json:"SelectedFaction,omitempty"`
 }
 
-// Message sent from client when they want to select a faction to play for the game.
+// Message sent from client when they want to select a faction to play for the game. After the
+// game has started, a player without a faction may send this to take over a faction that no other
+// player has, e.g. to rejoin after losing their connection.
 type SelectFactionMessage struct {
 	Faction game.PlayerFaction `json:"Faction"`
 }
@@ -56,25 +78,63 @@ type StartGameMessage struct{}
 // Message sent from server when the game starts.
 type GameStartedMessage struct {
 	Board game.Board `json:"Board"`
+
+	// The unit types that can be used in the game, including any defined by the board config.
+	UnitTypes game.UnitTypes `json:"UnitTypes"`
 }
 
 // Message sent from server to client to signal that client should submit orders.
 type OrderRequestMessage struct {
 	Season game.Season `json:"Season"`
+
+	// The player's current order draft, if they have one (e.g. from a rejected order set).
+	Draft []*game.Order `json:"Draft"`
+
+	// In winter: how many units the player can build or must disband, and why. Nil in other
+	// seasons.
+	BuildPlan *game.BuildPlan `json:"BuildPlan,omitempty"`
+}
+
+// Message sent from server to a client after their order draft was changed, with the validation
+// errors in the draft. The draft may be incomplete, so errors about the order set as a whole (such
+// as missing disband orders in winter) are expected until the player is done.
+//
+// Also sent in response to an [OrderDraftRequestMessage], and when a player rejoins the game while
+// it is waiting for their orders.
+type OrderDraftMessage struct {
+	Draft  []*game.Order `json:"Draft"`
+	Errors []OrderError  `json:"Errors"`
 }
 
-// Message sent from server to all clients when valid orders are received from a player.
-// Used to show who the server is waiting for.
+// Message sent from client to get their current order draft from the server, e.g. if the client's
+// copy was lost or is out of sync. Only the player that currently has the faction can request it.
+type OrderDraftRequestMessage struct{}
+
+// Message sent from server to all clients when valid orders are received from a player, or when a
+// player retracts their orders. Used to show who the server is waiting for.
 type OrdersConfirmationMessage struct {
 	FactionThatSubmittedOrders game.PlayerFaction `json:"FactionThatSubmittedOrders"`
+
+	// True if the faction retracted their previously submitted orders, meaning the server is
+	// waiting for them again.
+	Retracted bool `json:"Retracted"`
 }
 
-// Message sent from server to all clients when valid orders are received from all players.
+// Message sent from server to all clients when valid orders are received from all players. In
+// lobbies with hidden orders (see [LobbyOptions]), only contains the receiving player's own orders.
 type OrdersReceivedMessage struct {
 	// All orders will be non-nil.
 	OrdersByFaction map[game.PlayerFaction][]*game.Order `json:"OrdersByFaction"`
 }
 
+// Message sent from server to all clients after each round is resolved, in lobbies with hidden
+// orders (see [LobbyOptions]). Contains the orders of the round that touched a region where a
+// battle took place, along with the receiving player's own orders.
+type OrdersRevealedMessage struct {
+	// All orders will be non-nil.
+	OrdersByFaction map[game.PlayerFaction][]*game.Order `json:"OrdersByFaction"`
+}
+
 // Message sent from server to all clients when a battle has begun.
 type BattleAnnouncementMessage struct {
 	Battle game.Battle `json:"Battle"`
@@ -85,17 +145,118 @@ type BattleResultsMessage struct {
 	Battle game.Battle `json:"Battle"`
 }
 
+// Message sent from server to all clients after each resolution step, and at the end of each round,
+// with the changes to the board since the previous diff.
+type BoardDiffMessage struct {
+	Diff game.BoardDiff `json:"Diff"`
+}
+
+// Message sent from client to request a full copy of the board, e.g. if their board is out of sync.
+type BoardSnapshotRequestMessage struct{}
+
+// Message sent from server in response to a [BoardSnapshotRequestMessage]. The board is as of the
+// last [BoardDiffMessage] sent, so later diffs can be applied on top of it.
+type BoardSnapshotMessage struct {
+	Board  game.Board  `json:"Board"`
+	Season game.Season `json:"Season"`
+}
+
+// Message sent from server to all clients after each round is resolved, explaining the outcome of
+// every order.
+type RoundReportMessage struct {
+	// The season of the round that was resolved.
+	Season game.Season `json:"Season"`
+
+	// The events from resolving the round, in the order they happened.
+	Events []game.ResolutionEvent `json:"Events"`
+}
+
+// Message sent from server to all clients after each round is resolved, with each faction's
+// progress towards winning.
+type StandingsMessage struct {
+	// The season of the round that was resolved.
+	Season game.Season `json:"Season"`
+
+	// One entry per player faction.
+	Standings []game.Standing `json:"Standings"`
+}
+
+// Message sent from server to a client whose unit must retreat after a battle, in lobbies with the
+// retreat choice rule (see [game.Rules]). The client must respond with a [RetreatMessage].
+type RetreatRequestMessage struct {
+	Request game.RetreatRequest `json:"Request"`
+}
+
 // Message sent from server to all clients when the game is won.
 type WinnerMessage struct {
 	WinningFaction game.PlayerFaction `json:"WinningFaction"`
 }
 
+// Message sent from server to all clients when the game is stopped before any faction won, e.g.
+// because the lobby was abandoned. The lobby is closed after this message.
+type GameAbortedMessage struct {
+	Reason string `json:"Reason"`
+}
+
+// Message sent from server to all clients when the server is shutting down. Running games get until
+// the grace period is over to finish their current resolution step, after which the connection is
+// closed.
+type ServerShutdownMessage struct {
+	GracePeriodSeconds int `json:"GracePeriodSeconds"`
+}
+
 // Message sent from client when submitting orders.
 type SubmitOrdersMessage struct {
-	// All elements must be non-nil (checked in [Lobby.AwaitOrders]).
+	// All elements must be non-nil (checked in [Lobby.AwaitOrderInput]).
 	Orders []*game.Order `json:"Orders"`
 }
 
+// Message sent from client to retract their submitted orders, in order to change them. Players can
+// retract and resubmit orders until all players have submitted (a player can also amend their
+// orders by submitting a new order set directly).
+type RetractOrdersMessage struct{}
+
+// Message sent from client to add an order to the end of their order draft.
+type AddDraftOrderMessage struct {
+	Order *game.Order `json:"Order"`
+}
+
+// Message sent from client to replace the order at the given index in their order draft.
+type EditDraftOrderMessage struct {
+	Index int         `json:"Index"`
+	Order *game.Order `json:"Order"`
+}
+
+// Message sent from client to remove the order at the given index from their order draft.
+type RemoveDraftOrderMessage struct {
+	Index int `json:"Index"`
+}
+
+// Message sent from client to ask for the legal orders for their units, so that the client can
+// highlight valid targets. Only answered while the server is waiting for orders.
+type LegalOrdersRequestMessage struct {
+	// The region of the unit to get legal orders for. If blank, legal orders for all the player's
+	// regions are returned.
+	Region game.RegionName `json:"Region,omitempty"`
+}
+
+// Message sent from server in response to a [LegalOrdersRequestMessage]. Each order is legal on its
+// own, but orders may conflict with each other (see [game.LegalOrders]).
+type LegalOrdersMessage struct {
+	Region game.RegionName `json:"Region,omitempty"`
+	Orders []*game.Order   `json:"Orders"`
+}
+
+// Message sent from client in response to a [RetreatRequestMessage], to choose where their unit
+// retreats to.
+type RetreatMessage struct {
+	// The origin of the failed move in the retreat request, identifying the retreating unit.
+	RetreatingFrom game.RegionName `json:"RetreatingFrom"`
+
+	// Must be one of the destinations in the retreat request.
+	Destination game.RegionName `json:"Destination"`
+}
+
 // Message sent from client when they roll the dice in a battle.
 type DiceRollMessage struct{}
 
@@ -107,46 +268,128 @@ type GiveSupportMessage struct {
 	SupportedFaction game.PlayerFaction `json:"SupportedFaction,omitempty"`
 }
 
+// Identifies the type of a message's data.
+//
+// Tag values are part of the protocol between client and server, so they must never change once
+// released. New tags must be given a new, unused number (see [ProtocolVersion]).
 type MessageTag uint8
 
 const (
-	MessageTagError MessageTag = iota + 1
-	MessageTagLobbyJoined
-	MessageTagPlayerStatus
-	MessageTagSelectFaction
-	MessageTagStartGame
-	MessageTagGameStarted
-	MessageTagOrderRequest
-	MessageTagOrdersReceived
-	MessageTagOrdersConfirmation
-	MessageTagBattleAnnouncement
-	MessageTagBattleResults
-	MessageTagWinner
-	MessageTagSubmitOrders
-	MessageTagDiceRoll
-	MessageTagGiveSupport
+	MessageTagError                MessageTag = 1
+	MessageTagLobbyJoined          MessageTag = 2
+	MessageTagPlayerStatus         MessageTag = 3
+	MessageTagSelectFaction        MessageTag = 4
+	MessageTagStartGame            MessageTag = 5
+	MessageTagGameStarted          MessageTag = 6
+	MessageTagOrderRequest         MessageTag = 7
+	MessageTagOrdersConfirmation   MessageTag = 8
+	MessageTagOrdersReceived       MessageTag = 9
+	MessageTagBattleAnnouncement   MessageTag = 10
+	MessageTagBattleResults        MessageTag = 11
+	MessageTagWinner               MessageTag = 12
+	MessageTagSubmitOrders         MessageTag = 13
+	MessageTagDiceRoll             MessageTag = 14
+	MessageTagGiveSupport          MessageTag = 15
+	MessageTagGameAborted          MessageTag = 16
+	MessageTagServerShutdown       MessageTag = 17
+	MessageTagAddDraftOrder        MessageTag = 18
+	MessageTagEditDraftOrder       MessageTag = 19
+	MessageTagRemoveDraftOrder     MessageTag = 20
+	MessageTagOrderDraft           MessageTag = 21
+	MessageTagRetractOrders        MessageTag = 22
+	MessageTagLegalOrdersRequest   MessageTag = 23
+	MessageTagLegalOrders          MessageTag = 24
+	MessageTagStandings            MessageTag = 25
+	MessageTagBoardDiff            MessageTag = 26
+	MessageTagBoardSnapshotRequest MessageTag = 27
+	MessageTagBoardSnapshot        MessageTag = 28
+	MessageTagRoundReport          MessageTag = 29
+	MessageTagOrdersRevealed       MessageTag = 30
+	MessageTagRetreatRequest       MessageTag = 31
+	MessageTagRetreat              MessageTag = 32
+	MessageTagOrderDraftRequest    MessageTag = 33
 )
 
 var messageTags = enumnames.NewMap(
 	map[MessageTag]string{
-		MessageTagError:              "Error",
-		MessageTagLobbyJoined:        "LobbyJoined",
-		MessageTagPlayerStatus:       "PlayerStatus",
-		MessageTagSelectFaction:      "SelectFaction",
-		MessageTagStartGame:          "StartGame",
-		MessageTagGameStarted:        "GameStarted",
-		MessageTagOrderRequest:       "OrderRequest",
-		MessageTagOrdersConfirmation: "OrdersConfirmation",
-		MessageTagOrdersReceived:     "OrdersReceived",
-		MessageTagBattleAnnouncement: "BattleAnnouncement",
-		MessageTagBattleResults:      "BattleResults",
-		MessageTagWinner:             "Winner",
-		MessageTagSubmitOrders:       "SubmitOrders",
-		MessageTagDiceRoll:           "DiceRoll",
-		MessageTagGiveSupport:        "GiveSupport",
+		MessageTagError:                "Error",
+		MessageTagLobbyJoined:          "LobbyJoined",
+		MessageTagPlayerStatus:         "PlayerStatus",
+		MessageTagSelectFaction:        "SelectFaction",
+		MessageTagStartGame:            "StartGame",
+		MessageTagGameStarted:          "GameStarted",
+		MessageTagOrderRequest:         "OrderRequest",
+		MessageTagOrdersConfirmation:   "OrdersConfirmation",
+		MessageTagOrdersReceived:       "OrdersReceived",
+		MessageTagBattleAnnouncement:   "BattleAnnouncement",
+		MessageTagBattleResults:        "BattleResults",
+		MessageTagWinner:               "Winner",
+		MessageTagSubmitOrders:         "SubmitOrders",
+		MessageTagDiceRoll:             "DiceRoll",
+		MessageTagGiveSupport:          "GiveSupport",
+		MessageTagGameAborted:          "GameAborted",
+		MessageTagServerShutdown:       "ServerShutdown",
+		MessageTagAddDraftOrder:        "AddDraftOrder",
+		MessageTagEditDraftOrder:       "EditDraftOrder",
+		MessageTagRemoveDraftOrder:     "RemoveDraftOrder",
+		MessageTagOrderDraft:           "OrderDraft",
+		MessageTagRetractOrders:        "RetractOrders",
+		MessageTagLegalOrdersRequest:   "LegalOrdersRequest",
+		MessageTagLegalOrders:          "LegalOrders",
+		MessageTagStandings:            "Standings",
+		MessageTagBoardDiff:            "BoardDiff",
+		MessageTagBoardSnapshotRequest: "BoardSnapshotRequest",
+		MessageTagBoardSnapshot:        "BoardSnapshot",
+		MessageTagRoundReport:          "RoundReport",
+		MessageTagOrdersRevealed:       "OrdersRevealed",
+		MessageTagRetreatRequest:       "RetreatRequest",
+		MessageTagRetreat:              "Retreat",
+		MessageTagOrderDraftRequest:    "OrderDraftRequest",
 	},
 )
 
 func (tag MessageTag) String() string {
 	return messageTags.GetNameOrFallback(tag, "INVALID")
 }
+
+// Returns all valid message tags (used for generating protocol schemas).
+func (MessageTag) Values() []MessageTag {
+	return messageTags.Keys()
+}
+
+// The type of [Message.Data] for each message tag (used for generating protocol schemas).
+var MessageDataTypes = map[MessageTag]reflect.Type{
+	MessageTagError:                reflect.TypeFor[ErrorMessage](),
+	MessageTagLobbyJoined:          reflect.TypeFor[LobbyJoinedMessage](),
+	MessageTagPlayerStatus:         reflect.TypeFor[PlayerStatusMessage](),
+	MessageTagSelectFaction:        reflect.TypeFor[SelectFactionMessage](),
+	MessageTagStartGame:            reflect.TypeFor[StartGameMessage](),
+	MessageTagGameStarted:          reflect.TypeFor[GameStartedMessage](),
+	MessageTagOrderRequest:         reflect.TypeFor[OrderRequestMessage](),
+	MessageTagOrdersConfirmation:   reflect.TypeFor[OrdersConfirmationMessage](),
+	MessageTagOrdersReceived:       reflect.TypeFor[OrdersReceivedMessage](),
+	MessageTagBattleAnnouncement:   reflect.TypeFor[BattleAnnouncementMessage](),
+	MessageTagBattleResults:        reflect.TypeFor[BattleResultsMessage](),
+	MessageTagWinner:               reflect.TypeFor[WinnerMessage](),
+	MessageTagSubmitOrders:         reflect.TypeFor[SubmitOrdersMessage](),
+	MessageTagDiceRoll:             reflect.TypeFor[DiceRollMessage](),
+	MessageTagGiveSupport:          reflect.TypeFor[GiveSupportMessage](),
+	MessageTagGameAborted:          reflect.TypeFor[GameAbortedMessage](),
+	MessageTagServerShutdown:       reflect.TypeFor[ServerShutdownMessage](),
+	MessageTagAddDraftOrder:        reflect.TypeFor[AddDraftOrderMessage](),
+	MessageTagEditDraftOrder:       reflect.TypeFor[EditDraftOrderMessage](),
+	MessageTagRemoveDraftOrder:     reflect.TypeFor[RemoveDraftOrderMessage](),
+	MessageTagOrderDraft:           reflect.TypeFor[OrderDraftMessage](),
+	MessageTagRetractOrders:        reflect.TypeFor[RetractOrdersMessage](),
+	MessageTagLegalOrdersRequest:   reflect.TypeFor[LegalOrdersRequestMessage](),
+	MessageTagLegalOrders:          reflect.TypeFor[LegalOrdersMessage](),
+	MessageTagStandings:            reflect.TypeFor[StandingsMessage](),
+	MessageTagBoardDiff:            reflect.TypeFor[BoardDiffMessage](),
+	MessageTagBoardSnapshotRequest: reflect.TypeFor[BoardSnapshotRequestMessage](),
+	MessageTagBoardSnapshot:        reflect.TypeFor[BoardSnapshotMessage](),
+	MessageTagRoundReport:          reflect.TypeFor[RoundReportMessage](),
+	MessageTagOrdersRevealed:       reflect.TypeFor[OrdersRevealedMessage](),
+	MessageTagRetreatRequest:       reflect.TypeFor[RetreatRequestMessage](),
+	MessageTagRetreat:              reflect.TypeFor[RetreatMessage](),
+	MessageTagOrderDraftRequest:    reflect.TypeFor[OrderDraftRequestMessage](),
+}
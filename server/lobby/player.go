@@ -1,6 +1,7 @@
 package lobby
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"sync"
@@ -16,7 +17,8 @@ type Player struct {
 	username Username
 	// Must hold lock to access safely.
 	socket *websocket.Conn
-	// Blank until selected. Must hold lock to access safely before the game has started.
+	// Blank until selected. Must hold lock to access safely, except from the player's own message
+	// reading goroutine, which is the only one to change it.
 	gameFaction game.PlayerFaction
 	lock        sync.RWMutex
 	log         log.Logger
@@ -70,3 +72,28 @@ func (player *Player) selectFaction(faction game.PlayerFaction, lobby *Lobby) er
 	player.gameFaction = faction
 	return nil
 }
+
+// Lets a player without a faction take over a faction in a running game, e.g. after reconnecting.
+// Sends them the board and, if the game is waiting for their orders, their order draft.
+func (player *Player) rejoinGame(faction game.PlayerFaction, lobby *Lobby) error {
+	player.lock.RLock()
+	currentFaction := player.gameFaction
+	player.lock.RUnlock()
+
+	if currentFaction != "" {
+		return fmt.Errorf("already playing as '%s'", currentFaction)
+	}
+	if faction == "" {
+		return errors.New("no faction given")
+	}
+
+	if err := player.selectFaction(faction, lobby); err != nil {
+		return err
+	}
+	player.log.Info(nil, "Rejoined game", "faction", faction)
+
+	lobby.SendPlayerStatusMessage(player)
+	player.SendBoardSnapshot(lobby)
+	player.SendOrderDraft(lobby)
+	return nil
+}
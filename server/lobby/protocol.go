@@ -0,0 +1,61 @@
+package lobby
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Version of the WebSocket protocol between client and server. Clients send the version they
+// implement in the "protocolVersion" query parameter when joining a lobby.
+//
+// Must be incremented when making a change to messages that old clients cannot handle, such as
+// removing or renaming fields, or changing the meaning of a [MessageTag]. Adding new message types
+// or optional fields does not require a new version.
+//
+// Version history:
+//   - 1: First versioned protocol, with explicitly numbered message tags. Compared to the earlier
+//     unversioned servers, the numbers of [MessageTagOrdersConfirmation] (now 8) and
+//     [MessageTagOrdersReceived] (now 9) are swapped. This is a deliberate fix: the client already
+//     used these numbers, so unversioned servers sent each message under the other's tag.
+//     Unversioned clients do not send a protocol version, so they are rejected when joining
+//     instead of misreading these tags.
+const ProtocolVersion = 1
+
+// The oldest client protocol version that the server is still compatible with.
+const MinSupportedProtocolVersion = 1
+
+// Checks that the protocol version sent by a client is compatible with the server.
+func CheckProtocolVersion(clientVersion string) error {
+	if clientVersion == "" {
+		return fmt.Errorf(
+			"client did not specify protocol version, server requires version %d to %d",
+			MinSupportedProtocolVersion,
+			ProtocolVersion,
+		)
+	}
+
+	version, err := strconv.Atoi(clientVersion)
+	if err != nil {
+		return fmt.Errorf("invalid client protocol version '%s'", clientVersion)
+	}
+
+	if version < MinSupportedProtocolVersion {
+		return fmt.Errorf(
+			"client protocol version %d is no longer supported by server (minimum %d), "+
+				"client must be updated",
+			version,
+			MinSupportedProtocolVersion,
+		)
+	}
+
+	if version > ProtocolVersion {
+		return fmt.Errorf(
+			"client protocol version %d is newer than server protocol version %d, "+
+				"server must be updated",
+			version,
+			ProtocolVersion,
+		)
+	}
+
+	return nil
+}
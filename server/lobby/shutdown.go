@@ -0,0 +1,134 @@
+package lobby
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"hermannm.dev/wrap"
+)
+
+var errServerShutdown = errors.New("server is shutting down")
+
+// Stops accepting new lobbies, and shuts down all existing lobbies. Players are notified of the
+// shutdown, and running games are given until the given context is canceled to finish their
+// current resolution step. If saveDir is not blank, the state of games that stopped in time is
+// saved as JSON files in that directory.
+func (registry *LobbyRegistry) Shutdown(ctx context.Context, saveDir string) {
+	registry.shuttingDown.Store(true)
+
+	registry.lock.RLock()
+	lobbies := make([]*Lobby, len(registry.lobbies))
+	copy(lobbies, registry.lobbies)
+	registry.lock.RUnlock()
+
+	var waitGroup sync.WaitGroup
+	for _, lobby := range lobbies {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			lobby.shutdown(ctx, saveDir)
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// Whether the server is shutting down, in which case no new lobbies or players are accepted.
+func (registry *LobbyRegistry) ShuttingDown() bool {
+	return registry.shuttingDown.Load()
+}
+
+func (lobby *Lobby) shutdown(ctx context.Context, saveDir string) {
+	gracePeriod := time.Duration(0)
+	if deadline, ok := ctx.Deadline(); ok {
+		gracePeriod = time.Until(deadline)
+	}
+	lobby.SendServerShutdown(gracePeriod)
+
+	lobby.lock.RLock()
+	gameRunning := lobby.cancelGame != nil
+	lobby.lock.RUnlock()
+
+	if !gameRunning {
+		lobby.Close(errServerShutdown.Error())
+		return
+	}
+
+	lobby.game.StopAfterCurrentStep(errServerShutdown)
+
+	select {
+	case <-lobby.gameDone:
+		if saveDir != "" {
+			lobby.saveGame(saveDir)
+		}
+	case <-ctx.Done():
+		lobby.log.Warn(
+			nil,
+			"Game did not finish its current resolution step within shutdown grace period, aborting",
+		)
+		lobby.Close(errServerShutdown.Error())
+		<-lobby.gameDone
+	}
+}
+
+// Saves the lobby's game state to a JSON file named after the lobby in the given directory.
+// Should only be called after the game has stopped.
+func (lobby *Lobby) saveGame(saveDir string) {
+	if err := os.MkdirAll(saveDir, 0o750); err != nil {
+		lobby.log.Error(nil, wrap.Error(err, "failed to create directory for saved games"), "")
+		return
+	}
+
+	path := filepath.Join(saveDir, url.PathEscape(lobby.name)+".json")
+	file, err := os.Create(path) //nolint:gosec // Lobby name is escaped above
+	if err != nil {
+		lobby.log.Error(nil, wrap.Error(err, "failed to create save file"), "", "path", path)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			lobby.log.Error(nil, wrap.Error(err, "failed to close save file"), "", "path", path)
+		}
+	}()
+
+	if err := lobby.game.Save(file); err != nil {
+		lobby.log.Error(nil, err, "Failed to save game", "path", path)
+		return
+	}
+
+	lobby.log.Info(nil, "Saved game state", "path", path)
+}
+
+// Timeout for writing the WebSocket close frame when disconnecting a player.
+const closeFrameTimeout = 1 * time.Second
+
+// Maximum length of the reason text in a WebSocket close frame, per RFC 6455.
+const maxCloseReasonLength = 123
+
+// Sends a WebSocket close frame with the given code and reason to the player, then closes the
+// socket connection.
+func (player *Player) closeConnection(closeCode int, reason string) {
+	player.lock.Lock()
+	defer player.lock.Unlock()
+
+	if len(reason) > maxCloseReasonLength {
+		reason = reason[:maxCloseReasonLength]
+	}
+
+	if err := player.socket.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(closeCode, reason),
+		time.Now().Add(closeFrameTimeout),
+	); err != nil {
+		player.log.Error(nil, err, "Failed to send close frame")
+	}
+
+	if err := player.socket.Close(); err != nil {
+		player.log.Error(nil, err, "Failed to close socket connection")
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
@@ -28,7 +30,8 @@ func main() {
 
 	ctx := context.Background()
 
-	local, devMode, port := getCommandLineFlags()
+	flags := getCommandLineFlags()
+	local, devMode, port := flags.local, flags.devMode, flags.port
 
 	availableBoards, err := game.GetAvailableBoards()
 	if err != nil {
@@ -36,7 +39,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	lobbyRegistry := lobby.NewLobbyRegistry()
+	lobbyRegistry := lobby.NewLobbyRegistry(flags.lobbyExpiry)
 	lobbyAPI := api.NewLobbyAPI(http.DefaultServeMux, lobbyRegistry, availableBoards)
 
 	if local || devMode {
@@ -47,29 +50,101 @@ func main() {
 		lobbyAPI.RegisterLobbyCreationEndpoints()
 	}
 
-	log.Infof(ctx, "Listening on port %s...", port)
-	if err := lobbyAPI.ListenAndServe(fmt.Sprintf(":%s", port)); err != nil {
+	// Listens for shutdown signals only after the interactive setup above, so that the user can
+	// still cancel that with Ctrl+C
+	ctx, stopListeningForSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+
+	go lobbyRegistry.CloseExpiredLobbies(ctx)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Infof(ctx, "Listening on port %s...", port)
+		serverErr <- lobbyAPI.ListenAndServe(fmt.Sprintf(":%s", port))
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Error(ctx, err, "Server stopped")
 		os.Exit(1)
+	case <-ctx.Done():
 	}
+
+	// Restores default signal behavior, so that a second signal forcefully stops the server
+	stopListeningForSignals()
+
+	log.Infof(
+		context.Background(),
+		"Shutting down, giving running games %s to finish...",
+		flags.shutdownGracePeriod,
+	)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), flags.shutdownGracePeriod)
+	defer cancel()
+
+	if err := lobbyAPI.Shutdown(shutdownCtx, flags.saveDir); err != nil {
+		log.Error(shutdownCtx, err, "Failed to shut down server gracefully")
+		return
+	}
+	log.Info(shutdownCtx, "Server shut down")
 }
 
-func getCommandLineFlags() (local bool, devMode bool, port string) {
-	flag.BoolVar(&local, "local", false, "Disable public endpoints for creating new lobbies")
+type commandLineFlags struct {
+	local               bool
+	devMode             bool
+	port                string
+	lobbyExpiry         lobby.LobbyExpiry
+	shutdownGracePeriod time.Duration
+	saveDir             string
+}
+
+//nolint:exhaustruct
+func getCommandLineFlags() commandLineFlags {
+	var flags commandLineFlags
+
+	flag.BoolVar(&flags.local, "local", false, "Disable public endpoints for creating new lobbies")
 	flag.BoolVar(
-		&devMode,
+		&flags.devMode,
 		"dev",
 		false,
 		"Allows for creating single-player lobbies for development",
 	)
 	flag.StringVar(
-		&port,
+		&flags.port,
 		"port",
 		defaultPort,
 		"The port on which the server should handle requests",
 	)
+	flag.DurationVar(
+		&flags.lobbyExpiry.EmptyLobbyTimeout,
+		"empty-lobby-timeout",
+		10*time.Minute,
+		"Close lobbies that have had no players for this long (0 to disable)",
+	)
+	flag.DurationVar(
+		&flags.lobbyExpiry.UnstartedLobbyTimeout,
+		"unstarted-lobby-timeout",
+		2*time.Hour,
+		"Close lobbies that have not started their game after this long (0 to disable)",
+	)
+	flag.DurationVar(
+		&flags.lobbyExpiry.StalledGameTimeout,
+		"stalled-game-timeout",
+		1*time.Hour,
+		"Close lobbies whose game has received no player messages for this long (0 to disable)",
+	)
+	flag.DurationVar(
+		&flags.shutdownGracePeriod,
+		"shutdown-grace-period",
+		30*time.Second,
+		"On shutdown, how long to give running games to finish their current resolution step",
+	)
+	flag.StringVar(
+		&flags.saveDir,
+		"save-dir",
+		"",
+		"Directory in which to save the state of running games on shutdown (disabled if blank)",
+	)
 	flag.Parse()
-	return local, devMode, port
+	return flags
 }
 
 //nolint:forbidigo
@@ -145,6 +220,13 @@ func createLobby(
 			selectedBoard.ID,
 			true,
 			customFactions,
+			lobby.LobbyOptions{
+				Password:     "",
+				Unlisted:     false,
+				FogOfWar:     false,
+				HiddenOrders: false,
+				Rules:        game.Rules{RetreatChoice: false, HoldDefenseBonus: 0, Economy: false},
+			},
 		); err != nil {
 			fmt.Printf("Got error: '%s', try again!\n", err.Error())
 			continue
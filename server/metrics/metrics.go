@@ -0,0 +1,307 @@
+// Package metrics implements a minimal registry of counters, gauges and histograms, which can be
+// exposed in the Prometheus text format without depending on an external client library.
+//
+// See https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The registry that the game server's metrics are registered on.
+var Default = NewRegistry()
+
+type Registry struct {
+	metrics []metric // Sorted by name. Must hold lock to access safely.
+	lock    sync.RWMutex
+}
+
+type metric interface {
+	name() string
+	write(writer io.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: nil, lock: sync.RWMutex{}}
+}
+
+// Adds the given metric to the registry. If a metric of the same name is already registered, it is
+// replaced.
+func (registry *Registry) register(newMetric metric) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	index, found := slices.BinarySearchFunc(
+		registry.metrics,
+		newMetric.name(),
+		func(existing metric, name string) int {
+			return strings.Compare(existing.name(), name)
+		},
+	)
+	if found {
+		registry.metrics[index] = newMetric
+	} else {
+		registry.metrics = slices.Insert(registry.metrics, index, newMetric)
+	}
+}
+
+// Writes all registered metrics to the given writer in the Prometheus text format.
+func (registry *Registry) WriteText(writer io.Writer) error {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	for _, metric := range registry.metrics {
+		if err := metric.write(writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A metric that only goes up, optionally partitioned by label values.
+type Counter struct {
+	metricName string
+	help       string
+	labelNames []string
+	values     map[string]float64 // Keyed by formatted labels. Must hold lock to access safely.
+	lock       sync.Mutex
+}
+
+// Creates a counter and registers it on the registry. If label names are given, the same number
+// of label values must be passed to [Counter.Inc] and [Counter.Add].
+func (registry *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	counter := &Counter{
+		metricName: name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+		lock:       sync.Mutex{},
+	}
+	if len(labelNames) == 0 {
+		counter.values[""] = 0 // Exposes unlabeled counters before they are first incremented
+	}
+	registry.register(counter)
+	return counter
+}
+
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	labels := formatLabels(counter.labelNames, labelValues)
+
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+
+	counter.values[labels] += value
+}
+
+func (counter *Counter) name() string {
+	return counter.metricName
+}
+
+func (counter *Counter) write(writer io.Writer) error {
+	counter.lock.Lock()
+	defer counter.lock.Unlock()
+
+	if err := writeHeader(writer, counter.metricName, counter.help, "counter"); err != nil {
+		return err
+	}
+
+	labelSets := make([]string, 0, len(counter.values))
+	for labels := range counter.values {
+		labelSets = append(labelSets, labels)
+	}
+	slices.Sort(labelSets)
+
+	for _, labels := range labelSets {
+		if err := writeSample(
+			writer,
+			counter.metricName,
+			labels,
+			counter.values[labels],
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A metric whose value is computed by calling a function whenever metrics are collected.
+type GaugeFunc struct {
+	metricName string
+	help       string
+	getValue   func() float64
+}
+
+// Creates a gauge that is computed by the given function, and registers it on the registry.
+func (registry *Registry) NewGaugeFunc(name string, help string, getValue func() float64) {
+	registry.register(&GaugeFunc{metricName: name, help: help, getValue: getValue})
+}
+
+func (gauge *GaugeFunc) name() string {
+	return gauge.metricName
+}
+
+func (gauge *GaugeFunc) write(writer io.Writer) error {
+	if err := writeHeader(writer, gauge.metricName, gauge.help, "gauge"); err != nil {
+		return err
+	}
+	return writeSample(writer, gauge.metricName, "", gauge.getValue())
+}
+
+// A metric that counts observed values in configurable buckets.
+type Histogram struct {
+	metricName string
+	help       string
+	// Upper bounds of the buckets, in ascending order (excluding +Inf).
+	buckets []float64
+	// Number of observations for each bucket (not cumulative). Must hold lock to access safely.
+	bucketCounts []uint64
+	count        uint64  // Must hold lock to access safely.
+	sum          float64 // Must hold lock to access safely.
+	lock         sync.Mutex
+}
+
+// Creates a histogram with the given bucket upper bounds, and registers it on the registry.
+func (registry *Registry) NewHistogram(name string, help string, buckets []float64) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	histogram := &Histogram{
+		metricName:   name,
+		help:         help,
+		buckets:      buckets,
+		bucketCounts: make([]uint64, len(buckets)),
+		count:        0,
+		sum:          0,
+		lock:         sync.Mutex{},
+	}
+	registry.register(histogram)
+	return histogram
+}
+
+func (histogram *Histogram) Observe(value float64) {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+
+	histogram.count++
+	histogram.sum += value
+
+	for i, upperBound := range histogram.buckets {
+		if value <= upperBound {
+			histogram.bucketCounts[i]++
+			break
+		}
+	}
+}
+
+func (histogram *Histogram) name() string {
+	return histogram.metricName
+}
+
+func (histogram *Histogram) write(writer io.Writer) error {
+	histogram.lock.Lock()
+	defer histogram.lock.Unlock()
+
+	if err := writeHeader(writer, histogram.metricName, histogram.help, "histogram"); err != nil {
+		return err
+	}
+
+	bucketName := histogram.metricName + "_bucket"
+	var cumulativeCount uint64
+	for i, upperBound := range histogram.buckets {
+		cumulativeCount += histogram.bucketCounts[i]
+		labels := formatLabels([]string{"le"}, []string{formatFloat(upperBound)})
+		if err := writeSample(writer, bucketName, labels, float64(cumulativeCount)); err != nil {
+			return err
+		}
+	}
+
+	infLabels := formatLabels([]string{"le"}, []string{"+Inf"})
+	if err := writeSample(writer, bucketName, infLabels, float64(histogram.count)); err != nil {
+		return err
+	}
+	if err := writeSample(writer, histogram.metricName+"_sum", "", histogram.sum); err != nil {
+		return err
+	}
+	return writeSample(writer, histogram.metricName+"_count", "", float64(histogram.count))
+}
+
+func writeHeader(writer io.Writer, name string, help string, metricType string) error {
+	_, err := fmt.Fprintf(
+		writer,
+		"# HELP %s %s\n# TYPE %s %s\n",
+		name,
+		escapeHelp(help),
+		name,
+		metricType,
+	)
+	return err
+}
+
+func writeSample(writer io.Writer, name string, labels string, value float64) error {
+	_, err := fmt.Fprintf(writer, "%s%s %s\n", name, labels, formatFloat(value))
+	return err
+}
+
+// Formats labels as {name1="value1",name2="value2"}, or an empty string if there are no labels.
+// Panics if the number of names and values do not match, as that is a programming error.
+func formatLabels(names []string, values []string) string {
+	if len(names) != len(values) {
+		panic(
+			fmt.Sprintf("metric expected %d label values, got %d", len(names), len(values)),
+		)
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('{')
+	for i, name := range names {
+		if i != 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(name)
+		builder.WriteString(`="`)
+		builder.WriteString(escapeLabelValue(values[i]))
+		builder.WriteByte('"')
+	}
+	builder.WriteByte('}')
+	return builder.String()
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
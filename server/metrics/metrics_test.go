@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setup    func(registry *Registry)
+		expected string
+	}{
+		{
+			name: "UnlabeledCounter",
+			setup: func(registry *Registry) {
+				registry.NewCounter("unused_total", "Never incremented.")
+				counter := registry.NewCounter("requests_total", "Number of requests.")
+				counter.Inc()
+				counter.Add(2.5)
+			},
+			expected: `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total 3.5
+# HELP unused_total Never incremented.
+# TYPE unused_total counter
+unused_total 0
+`,
+		},
+		{
+			name: "LabeledCounter",
+			setup: func(registry *Registry) {
+				counter := registry.NewCounter("errors_total", "Errors by kind.", "kind", "code")
+				counter.Inc("timeout", "23")
+				counter.Inc("invalid", "4")
+				counter.Inc("timeout", "23")
+			},
+			expected: `# HELP errors_total Errors by kind.
+# TYPE errors_total counter
+errors_total{kind="invalid",code="4"} 1
+errors_total{kind="timeout",code="23"} 2
+`,
+		},
+		{
+			name: "Escaping",
+			setup: func(registry *Registry) {
+				counter := registry.NewCounter(
+					"escaped_total",
+					"Help with \\ backslash\nand newline.",
+					"value",
+				)
+				counter.Inc("quote \" backslash \\ newline \n end")
+			},
+			expected: `# HELP escaped_total Help with \\ backslash\nand newline.
+# TYPE escaped_total counter
+escaped_total{value="quote \" backslash \\ newline \n end"} 1
+`,
+		},
+		{
+			name: "Histogram",
+			setup: func(registry *Registry) {
+				histogram := registry.NewHistogram(
+					"duration_seconds",
+					"Durations.",
+					[]float64{5, 0.5, 2}, // Sorted by NewHistogram
+				)
+				for _, value := range []float64{0.25, 1, 1.5, 2, 4, 10} {
+					histogram.Observe(value)
+				}
+			},
+			expected: `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.5"} 1
+duration_seconds_bucket{le="2"} 4
+duration_seconds_bucket{le="5"} 5
+duration_seconds_bucket{le="+Inf"} 6
+duration_seconds_sum 18.75
+duration_seconds_count 6
+`,
+		},
+		{
+			name: "GaugeFunc",
+			setup: func(registry *Registry) {
+				registry.NewGaugeFunc("active_games", "Active games.", func() float64 { return 3 })
+			},
+			expected: `# HELP active_games Active games.
+# TYPE active_games gauge
+active_games 3
+`,
+		},
+		{
+			name: "ReplacedMetric",
+			setup: func(registry *Registry) {
+				registry.NewCounter("replaced_total", "Old.").Inc()
+				registry.NewCounter("replaced_total", "New.")
+			},
+			expected: `# HELP replaced_total New.
+# TYPE replaced_total counter
+replaced_total 0
+`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			registry := NewRegistry()
+			testCase.setup(registry)
+
+			var output strings.Builder
+			if err := registry.WriteText(&output); err != nil {
+				t.Fatal(err)
+			}
+
+			if output.String() != testCase.expected {
+				t.Errorf("want output:\n%s\ngot:\n%s", testCase.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestLabelCountMismatch(t *testing.T) {
+	counter := NewRegistry().NewCounter("labeled_total", "Labeled.", "kind")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic when passing wrong number of label values")
+		}
+	}()
+	counter.Inc()
+}
@@ -0,0 +1,21 @@
+//go:build mage
+
+// Run with "mage schema" to regenerate the JSON Schema for the client-server protocol, after
+// changing message or game types
+
+package main
+
+import (
+	"fmt"
+
+	"hermannm.dev/casus-belli/server/schema"
+)
+
+func Schema() error {
+	if err := schema.WriteFile(schema.DefaultPath); err != nil {
+		return err
+	}
+
+	fmt.Println(withColor("[Finished]", green), "Schema written to:", schema.DefaultPath)
+	return nil
+}
@@ -0,0 +1,364 @@
+// Package schema generates a JSON Schema for the WebSocket protocol between client and server,
+// from the Go types of message payloads and game state. Clients can use the schema to generate or
+// check their own message types.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"hermannm.dev/wrap"
+
+	"hermannm.dev/casus-belli/server/game"
+	"hermannm.dev/casus-belli/server/lobby"
+)
+
+// Path of the committed schema file, relative to the server module root.
+const DefaultPath = "schema/protocol.schema.json"
+
+// Game types that are always included in the schema definitions, even if no message refers to
+// them directly.
+var gameTypes = []reflect.Type{
+	reflect.TypeFor[game.Order](),
+	reflect.TypeFor[game.Battle](),
+	reflect.TypeFor[game.Result](),
+	reflect.TypeFor[game.Modifier](),
+	reflect.TypeFor[game.Region](),
+	reflect.TypeFor[game.Board](),
+}
+
+// Generates a JSON Schema describing every message that can be sent between client and server,
+// as indented JSON. Output is deterministic, so it can be compared against a committed file.
+func Generate() ([]byte, error) {
+	generator := schemaGenerator{defs: make(map[string]any), defTypes: make(map[string]reflect.Type)}
+
+	for _, gameType := range gameTypes {
+		if _, err := generator.schemaFor(gameType); err != nil {
+			return nil, err
+		}
+	}
+
+	tags := lobby.MessageTag(0).Values()
+	slices.Sort(tags)
+
+	messageSchemas := make([]any, 0, len(tags))
+	for _, tag := range tags {
+		dataType, ok := lobby.MessageDataTypes[tag]
+		if !ok {
+			return nil, fmt.Errorf("no data type registered for message tag '%s'", tag)
+		}
+
+		dataSchema, err := generator.schemaFor(dataType)
+		if err != nil {
+			return nil, wrap.Errorf(err, "failed to generate schema for message '%s'", tag)
+		}
+
+		messageSchemas = append(
+			messageSchemas,
+			map[string]any{
+				"title": tag.String(),
+				"type":  "object",
+				"properties": map[string]any{
+					"Tag":  map[string]any{"const": int(tag)},
+					"Data": dataSchema,
+				},
+				"required": []string{"Tag", "Data"},
+			},
+		)
+	}
+
+	root := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Casus Belli WebSocket message",
+		"$comment": fmt.Sprintf(
+			"Generated from Go types in the server. Protocol version %d.",
+			lobby.ProtocolVersion,
+		),
+		"oneOf": messageSchemas,
+		"$defs": generator.defs,
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(root); err != nil {
+		return nil, wrap.Error(err, "failed to serialize schema")
+	}
+	return buffer.Bytes(), nil
+}
+
+// Generates the schema and writes it to the file at the given path.
+func WriteFile(path string) error {
+	schema, err := Generate()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, schema, 0o644); err != nil { //nolint:gosec // Not a secret
+		return wrap.Errorf(err, "failed to write schema to '%s'", path)
+	}
+	return nil
+}
+
+// Checks that the schema file at the given path matches the schema generated from the current Go
+// types, to catch protocol changes that have not been exported for clients.
+func CheckFile(path string) error {
+	committed, err := os.ReadFile(path)
+	if err != nil {
+		return wrap.Errorf(err, "failed to read schema from '%s'", path)
+	}
+
+	generated, err := Generate()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(committed, generated) {
+		return fmt.Errorf(
+			"schema in '%s' is out of date with the protocol types: regenerate it with 'mage schema', "+
+				"update clients, and increment lobby.ProtocolVersion if the change is breaking",
+			path,
+		)
+	}
+	return nil
+}
+
+type schemaGenerator struct {
+	// JSON Schema definitions for named types, referred to by "#/$defs/{name}".
+	defs map[string]any
+	// Go types of the definitions in defs, to detect name collisions between packages.
+	defTypes map[string]reflect.Type
+}
+
+// Name of the method implemented by the server's integer enum types, returning all valid values.
+const enumMethodName = "Values"
+
+// Name of the method implemented by integer types that are enums with known values, but where other
+// values may also be valid (such as unit types defined in board configs).
+const openEnumMethodName = "ExampleValues"
+
+func (generator *schemaGenerator) schemaFor(goType reflect.Type) (map[string]any, error) {
+	if generator.isDefinedType(goType) {
+		return generator.refFor(goType)
+	}
+
+	return generator.inlineSchemaFor(goType)
+}
+
+// Named types from this module get their own definition, so that clients can map them to named
+// types of their own.
+func (generator *schemaGenerator) isDefinedType(goType reflect.Type) bool {
+	return goType.Name() != "" &&
+		strings.HasPrefix(goType.PkgPath(), "hermannm.dev/casus-belli/server/")
+}
+
+func (generator *schemaGenerator) refFor(goType reflect.Type) (map[string]any, error) {
+	name := goType.Name()
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+
+	if existingType, exists := generator.defTypes[name]; exists {
+		if existingType != goType {
+			return nil, fmt.Errorf(
+				"schema definition name '%s' used by both %s and %s",
+				name,
+				existingType,
+				goType,
+			)
+		}
+		return ref, nil
+	}
+
+	// Registers the type before generating its schema, to handle recursive types
+	generator.defTypes[name] = goType
+
+	var def map[string]any
+	var err error
+	if values, isEnum := goType.MethodByName(enumMethodName); isEnum {
+		def, err = enumSchema(goType, values)
+	} else if examples, isOpenEnum := goType.MethodByName(openEnumMethodName); isOpenEnum {
+		def, err = openEnumSchema(goType, examples)
+	} else {
+		def, err = generator.inlineSchemaFor(goType)
+	}
+	if err != nil {
+		return nil, wrap.Errorf(err, "failed to generate schema for type %s", goType)
+	}
+
+	generator.defs[name] = def
+	return ref, nil
+}
+
+func (generator *schemaGenerator) inlineSchemaFor(goType reflect.Type) (map[string]any, error) {
+	switch goType.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil // Any value
+	case reflect.Pointer:
+		elemSchema, err := generator.schemaFor(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"anyOf": []any{elemSchema, map[string]any{"type": "null"}}}, nil
+	case reflect.Slice, reflect.Array:
+		itemSchema, err := generator.schemaFor(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		// Nil slices are serialized as null
+		return map[string]any{"type": []string{"array", "null"}, "items": itemSchema}, nil
+	case reflect.Map:
+		var keySchema map[string]any
+		if goType.Key().Kind() == reflect.String {
+			var err error
+			if keySchema, err = generator.schemaFor(goType.Key()); err != nil {
+				return nil, err
+			}
+		} else {
+			// encoding/json serializes integer map keys as strings of digits
+			keySchema = map[string]any{"type": "string", "pattern": "^[0-9]+$"}
+		}
+		valueSchema, err := generator.schemaFor(goType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"type":                 []string{"object", "null"},
+			"propertyNames":        keySchema,
+			"additionalProperties": valueSchema,
+		}, nil
+	case reflect.Struct:
+		return generator.structSchema(goType)
+	default:
+		return nil, fmt.Errorf("unsupported kind '%s' for type %s", goType.Kind(), goType)
+	}
+}
+
+func (generator *schemaGenerator) structSchema(goType reflect.Type) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := []string{} // Serializes as an empty array rather than null
+
+	if err := generator.addStructFields(goType, properties, &required); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, nil
+}
+
+// Adds properties for the struct's fields, following the rules of encoding/json: unexported
+// fields are skipped, and fields of embedded structs are promoted to the outer struct.
+func (generator *schemaGenerator) addStructFields(
+	goType reflect.Type,
+	properties map[string]any,
+	required *[]string,
+) error {
+	for i := range goType.NumField() {
+		field := goType.Field(i)
+		jsonName, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && jsonName == "" {
+			if err := generator.addStructFields(field.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+
+		fieldSchema, err := generator.schemaFor(field.Type)
+		if err != nil {
+			return wrap.Errorf(err, "failed to generate schema for field '%s'", field.Name)
+		}
+		properties[jsonName] = fieldSchema
+
+		if !slices.Contains(strings.Split(options, ","), "omitempty") {
+			*required = append(*required, jsonName)
+		}
+	}
+
+	return nil
+}
+
+// Generates a schema for an integer enum type, with one constant for each of the values returned
+// by its Values method.
+func enumSchema(goType reflect.Type, valuesMethod reflect.Method) (map[string]any, error) {
+	values, err := callValuesMethod(goType, valuesMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	constants := make([]any, 0, values.Len())
+	for i := range values.Len() {
+		value := values.Index(i)
+		constants = append(
+			constants,
+			map[string]any{"const": value.Uint(), "title": fmt.Sprint(value.Interface())},
+		)
+	}
+
+	return map[string]any{"type": "integer", "oneOf": constants}, nil
+}
+
+// Generates a schema for an integer type that accepts any value, listing the values returned by
+// its ExampleValues method as examples.
+func openEnumSchema(goType reflect.Type, examplesMethod reflect.Method) (map[string]any, error) {
+	values, err := callValuesMethod(goType, examplesMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]any, 0, values.Len())
+	names := make([]string, 0, values.Len())
+	for i := range values.Len() {
+		value := values.Index(i)
+		examples = append(examples, value.Uint())
+		names = append(names, fmt.Sprintf("%d = %v", value.Uint(), value.Interface()))
+	}
+
+	return map[string]any{
+		"type":     "integer",
+		"examples": examples,
+		"description": fmt.Sprintf(
+			"Built-in values: %s. Other values may be used.",
+			strings.Join(names, ", "),
+		),
+	}, nil
+}
+
+func callValuesMethod(goType reflect.Type, method reflect.Method) (reflect.Value, error) {
+	values := method.Func.Call([]reflect.Value{reflect.Zero(goType)})[0]
+	if values.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf(
+			"%s method on enum type %s must return slice",
+			method.Name,
+			goType,
+		)
+	}
+	return values, nil
+}
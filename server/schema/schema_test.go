@@ -0,0 +1,13 @@
+package schema_test
+
+import (
+	"testing"
+
+	"hermannm.dev/casus-belli/server/schema"
+)
+
+func TestSchemaUpToDate(t *testing.T) {
+	if err := schema.CheckFile("protocol.schema.json"); err != nil {
+		t.Fatal(err)
+	}
+}